@@ -0,0 +1,363 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// AugmentConfig параметры аугментации обучающих изображений.
+// Вероятности задают, с каким шансом каждое преобразование применяется к примеру
+type AugmentConfig struct {
+	AffineProb  float64 // Вероятность аффинного преобразования
+	MaxRotation float64 // Максимальный поворот, градусы
+	MaxShift    float64 // Максимальный сдвиг, пиксели
+	MinScale    float64 // Минимальный масштаб
+	MaxScale    float64 // Максимальный масштаб
+	MaxShear    float64 // Максимальный скос, градусы
+
+	ElasticProb  float64 // Вероятность эластичной деформации
+	ElasticAlpha float64 // Амплитуда смещений, пиксели
+	ElasticSigma float64 // Сглаживание поля смещений
+
+	ErasingProb    float64 // Вероятность случайного стирания прямоугольника
+	ErasingMinArea float64 // Минимальная доля площади изображения
+	ErasingMaxArea float64 // Максимальная доля площади изображения
+
+	NoiseProb float64 // Вероятность гауссова шума
+	NoiseStd  float64 // Стандартное отклонение шума
+
+	MorphologyProb float64 // Вероятность утолщения или утончения штриха
+}
+
+// DefaultAugmentConfig возвращает умеренные параметры аугментации для MNIST
+func DefaultAugmentConfig() AugmentConfig {
+	return AugmentConfig{
+		AffineProb:  0.5,
+		MaxRotation: 15,
+		MaxShift:    2,
+		MinScale:    0.9,
+		MaxScale:    1.1,
+		MaxShear:    10,
+
+		ElasticProb:  0.3,
+		ElasticAlpha: 8,
+		ElasticSigma: 3,
+
+		ErasingProb:    0.1,
+		ErasingMinArea: 0.02,
+		ErasingMaxArea: 0.1,
+
+		NoiseProb: 0.2,
+		NoiseStd:  0.05,
+
+		MorphologyProb: 0.2,
+	}
+}
+
+// ParseAugmentProbabilities задает вероятности из строки вида
+// "affine=0.5,elastic=0.3,erasing=0.1,noise=0.2,morphology=0.2"
+func ParseAugmentProbabilities(spec string, config *AugmentConfig) error {
+	probabilities := map[string]*float64{
+		"affine":     &config.AffineProb,
+		"elastic":    &config.ElasticProb,
+		"erasing":    &config.ErasingProb,
+		"noise":      &config.NoiseProb,
+		"morphology": &config.MorphologyProb,
+	}
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, value, _ := strings.Cut(part, "=")
+		target, ok := probabilities[name]
+		if !ok {
+			return fmt.Errorf("неизвестное преобразование %q", name)
+		}
+
+		prob, err := strconv.ParseFloat(value, 64)
+		if err != nil || prob < 0 || prob > 1 {
+			return fmt.Errorf("неверная вероятность %q для %s", value, name)
+		}
+		*target = prob
+	}
+
+	return nil
+}
+
+// Augmenter применяет случайные преобразования к изображениям 28x28.
+// Использует собственный генератор, поэтому результат воспроизводим при одинаковом зерне
+type Augmenter struct {
+	Config AugmentConfig
+	rng    *rand.Rand
+}
+
+// NewAugmenter создает аугментатор с заданным зерном
+func NewAugmenter(config AugmentConfig, seed int64) *Augmenter {
+	return &Augmenter{
+		Config: config,
+		rng:    rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Apply возвращает аугментированную копию изображения
+func (a *Augmenter) Apply(image []float64) []float64 {
+	result := append([]float64(nil), image...)
+	c := a.Config
+
+	if a.rng.Float64() < c.MorphologyProb {
+		if a.rng.Intn(2) == 0 {
+			result = morphology(result, math.Max)
+		} else {
+			result = morphology(result, math.Min)
+		}
+	}
+
+	if a.rng.Float64() < c.AffineProb {
+		result = a.affine(result)
+	}
+
+	if a.rng.Float64() < c.ElasticProb {
+		result = a.elastic(result)
+	}
+
+	if a.rng.Float64() < c.ErasingProb {
+		result = a.erase(result)
+	}
+
+	if a.rng.Float64() < c.NoiseProb {
+		result = a.noise(result)
+	}
+
+	return result
+}
+
+// uniform возвращает случайное число в диапазоне [lo, hi)
+func (a *Augmenter) uniform(lo, hi float64) float64 {
+	return lo + a.rng.Float64()*(hi-lo)
+}
+
+// affine применяет случайный поворот, масштаб, скос и сдвиг относительно центра
+func (a *Augmenter) affine(image []float64) []float64 {
+	c := a.Config
+	angle := a.uniform(-c.MaxRotation, c.MaxRotation) * math.Pi / 180
+	shear := a.uniform(-c.MaxShear, c.MaxShear) * math.Pi / 180
+	scale := a.uniform(c.MinScale, c.MaxScale)
+	tx := a.uniform(-c.MaxShift, c.MaxShift)
+	ty := a.uniform(-c.MaxShift, c.MaxShift)
+
+	// Прямое преобразование M = R * Sh * S, для выборки используем обратное
+	cos, sin, k := math.Cos(angle), math.Sin(angle), math.Tan(shear)
+	m00, m01 := cos*scale, (cos*k-sin)*scale
+	m10, m11 := sin*scale, (sin*k+cos)*scale
+	det := m00*m11 - m01*m10
+	i00, i01 := m11/det, -m01/det
+	i10, i11 := -m10/det, m00/det
+
+	center := float64(ImageSide-1) / 2
+	result := make([]float64, ImagePixels)
+	for y := 0; y < ImageSide; y++ {
+		for x := 0; x < ImageSide; x++ {
+			dx := float64(x) - center - tx
+			dy := float64(y) - center - ty
+			sx := i00*dx + i01*dy + center
+			sy := i10*dx + i11*dy + center
+			result[y*ImageSide+x] = sampleBilinear(image, ImageSide, ImageSide, sx, sy)
+		}
+	}
+
+	return result
+}
+
+// elastic применяет эластичную деформацию (Simard et al., 2003):
+// случайное поле смещений сглаживается гауссовым фильтром
+func (a *Augmenter) elastic(image []float64) []float64 {
+	c := a.Config
+	fieldX := make([]float64, ImagePixels)
+	fieldY := make([]float64, ImagePixels)
+	for i := range fieldX {
+		fieldX[i] = a.uniform(-1, 1)
+		fieldY[i] = a.uniform(-1, 1)
+	}
+
+	fieldX = gaussianBlur(fieldX, ImageSide, ImageSide, c.ElasticSigma)
+	fieldY = gaussianBlur(fieldY, ImageSide, ImageSide, c.ElasticSigma)
+
+	// Нормируем поле, чтобы амплитуда не зависела от сглаживания
+	var maxAbs float64
+	for i := range fieldX {
+		maxAbs = math.Max(maxAbs, math.Max(math.Abs(fieldX[i]), math.Abs(fieldY[i])))
+	}
+	if maxAbs == 0 {
+		return image
+	}
+	factor := c.ElasticAlpha / maxAbs / 4
+
+	result := make([]float64, ImagePixels)
+	for y := 0; y < ImageSide; y++ {
+		for x := 0; x < ImageSide; x++ {
+			idx := y*ImageSide + x
+			sx := float64(x) + fieldX[idx]*factor
+			sy := float64(y) + fieldY[idx]*factor
+			result[idx] = sampleBilinear(image, ImageSide, ImageSide, sx, sy)
+		}
+	}
+
+	return result
+}
+
+// erase стирает случайный прямоугольник (изменяет переданное изображение)
+func (a *Augmenter) erase(image []float64) []float64 {
+	c := a.Config
+	area := a.uniform(c.ErasingMinArea, c.ErasingMaxArea) * ImagePixels
+	aspect := math.Exp(a.uniform(math.Log(0.3), math.Log(3.3)))
+
+	w := min(ImageSide, max(1, int(math.Round(math.Sqrt(area*aspect)))))
+	h := min(ImageSide, max(1, int(math.Round(math.Sqrt(area/aspect)))))
+	x0 := a.rng.Intn(ImageSide - w + 1)
+	y0 := a.rng.Intn(ImageSide - h + 1)
+
+	for y := y0; y < y0+h; y++ {
+		for x := x0; x < x0+w; x++ {
+			image[y*ImageSide+x] = 0
+		}
+	}
+
+	return image
+}
+
+// noise добавляет гауссов шум
+func (a *Augmenter) noise(image []float64) []float64 {
+	result := make([]float64, len(image))
+	for i, value := range image {
+		result[i] = min(max(value+a.rng.NormFloat64()*a.Config.NoiseStd, 0), 1)
+	}
+	return result
+}
+
+// morphology применяет дилатацию (math.Max) или эрозию (math.Min) с крестовым ядром 3x3
+func morphology(image []float64, op func(float64, float64) float64) []float64 {
+	result := make([]float64, ImagePixels)
+	offsets := [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+
+	for y := 0; y < ImageSide; y++ {
+		for x := 0; x < ImageSide; x++ {
+			value := image[y*ImageSide+x]
+			for _, offset := range offsets {
+				nx, ny := x+offset[0], y+offset[1]
+				neighbour := 0.0
+				if nx >= 0 && nx < ImageSide && ny >= 0 && ny < ImageSide {
+					neighbour = image[ny*ImageSide+nx]
+				}
+				value = op(value, neighbour)
+			}
+			result[y*ImageSide+x] = value
+		}
+	}
+
+	return result
+}
+
+// sampleBilinear возвращает значение изображения в дробной точке (вне поля — 0)
+func sampleBilinear(image []float64, w, h int, x, y float64) float64 {
+	x0, y0 := int(math.Floor(x)), int(math.Floor(y))
+	fx, fy := x-float64(x0), y-float64(y0)
+
+	at := func(px, py int) float64 {
+		if px < 0 || px >= w || py < 0 || py >= h {
+			return 0
+		}
+		return image[py*w+px]
+	}
+
+	top := at(x0, y0)*(1-fx) + at(x0+1, y0)*fx
+	bottom := at(x0, y0+1)*(1-fx) + at(x0+1, y0+1)*fx
+	return top*(1-fy) + bottom*fy
+}
+
+// gaussianBlur размывает изображение разделимым гауссовым фильтром
+func gaussianBlur(pix []float64, w, h int, sigma float64) []float64 {
+	radius := int(math.Ceil(3 * sigma))
+	kernel := make([]float64, 2*radius+1)
+	var sum float64
+	for i := range kernel {
+		d := float64(i - radius)
+		kernel[i] = math.Exp(-d * d / (2 * sigma * sigma))
+		sum += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+
+	blur := func(src []float64, stepX, stepY int) []float64 {
+		dst := make([]float64, w*h)
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				var value float64
+				for i, weight := range kernel {
+					nx := min(max(x+(i-radius)*stepX, 0), w-1)
+					ny := min(max(y+(i-radius)*stepY, 0), h-1)
+					value += src[ny*w+nx] * weight
+				}
+				dst[y*w+x] = value
+			}
+		}
+		return dst
+	}
+
+	return blur(blur(pix, 1, 0), 0, 1)
+}
+
+// runAugmentPreview сохраняет исходные и аугментированные примеры в PNG
+func runAugmentPreview(args []string) error {
+	flags := flag.NewFlagSet("augment", flag.ExitOnError)
+	from := flags.String("from", "bin:data/test", "исходный набор <формат>:<путь>")
+	out := flags.String("out", "augmented", "каталог для изображений")
+	num := flags.Int("n", 10, "количество исходных изображений")
+	variants := flags.Int("variants", 8, "количество вариантов на изображение")
+	probs := flags.String("p", "", "вероятности преобразований, например affine=0.5,elastic=0.3")
+	seed := flags.Int64("seed", 1, "зерно генератора аугментации")
+	flags.Parse(args)
+
+	config := DefaultAugmentConfig()
+	if err := ParseAugmentProbabilities(*probs, &config); err != nil {
+		return err
+	}
+
+	dataset, err := LoadDataset(*from)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		return err
+	}
+
+	augmenter := NewAugmenter(config, *seed)
+	for i := 0; i < *num && i < dataset.Len(); i++ {
+		image, label := dataset.Images[i], dataset.Labels[i]
+
+		filename := filepath.Join(*out, fmt.Sprintf("sample_%d_label_%d.png", i, label))
+		if err := SaveImageAsPNG(image, filename, label); err != nil {
+			return err
+		}
+
+		for v := 0; v < *variants; v++ {
+			filename := filepath.Join(*out, fmt.Sprintf("sample_%d_label_%d_aug_%d.png", i, label, v))
+			if err := SaveImageAsPNG(augmenter.Apply(image), filename, label); err != nil {
+				return err
+			}
+		}
+	}
+
+	fmt.Printf("Сохранено %d примеров по %d вариантов в %s\n", min(*num, dataset.Len()), *variants, *out)
+	return nil
+}
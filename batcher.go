@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// errBatcherClosed возвращается запросам, пришедшим после Close
+var errBatcherClosed = errors.New("очередь предсказаний закрыта")
+
+// Batcher собирает одновременные запросы предсказаний в батчи: батч отправляется,
+// когда набрано MaxBatch входов или с момента первого запроса прошло Timeout
+type Batcher struct {
+	model    *Model
+	maxBatch int
+	timeout  time.Duration
+	requests chan *batchRequest
+	done     chan struct{}
+	stopOnce sync.Once
+
+	mu       sync.Mutex
+	batches  int64
+	total    int64
+	sizes    []int64 // Количество батчей каждого размера, индекс - размер
+	lastSize int
+}
+
+// batchRequest один вход в очереди и канал для ответа
+type batchRequest struct {
+	input  []float64
+	result chan batchResult
+}
+
+// batchResult результат предсказания для одного входа
+type batchResult struct {
+	output []float64
+	source string
+	err    error
+}
+
+// BatchStats статистика размеров батчей
+type BatchStats struct {
+	MaxBatch  int           `json:"max_batch"`
+	TimeoutMs float64       `json:"timeout_ms"`
+	Batches   int64         `json:"batches"`
+	Requests  int64         `json:"requests"`
+	MeanSize  float64       `json:"mean_batch_size"`
+	LastSize  int           `json:"last_batch_size"`
+	Sizes     map[int]int64 `json:"batch_sizes"` // Размер батча -> количество батчей
+}
+
+// NewBatcher создает очередь и запускает горутину, выполняющую батчи
+func NewBatcher(model *Model, maxBatch int, timeout time.Duration) *Batcher {
+	b := &Batcher{
+		model:    model,
+		maxBatch: max(maxBatch, 1),
+		timeout:  timeout,
+		requests: make(chan *batchRequest),
+		done:     make(chan struct{}),
+	}
+	b.sizes = make([]int64, b.maxBatch+1)
+	go b.run()
+	return b
+}
+
+// Predict ставит вход в очередь и ждет результат. Возвращает вероятности классов
+// и источник модели, которая их вычислила
+func (b *Batcher) Predict(ctx context.Context, input []float64) ([]float64, string, error) {
+	request := &batchRequest{input: input, result: make(chan batchResult, 1)}
+
+	select {
+	case b.requests <- request:
+	case <-b.done:
+		return nil, "", errBatcherClosed
+	case <-ctx.Done():
+		return nil, "", ctx.Err()
+	}
+
+	select {
+	case result := <-request.result:
+		return result.output, result.source, result.err
+	case <-ctx.Done():
+		return nil, "", ctx.Err()
+	}
+}
+
+// Close останавливает очередь, уже принятые запросы будут выполнены
+func (b *Batcher) Close() {
+	b.stopOnce.Do(func() { close(b.done) })
+}
+
+// Stats возвращает статистику размеров батчей
+func (b *Batcher) Stats() BatchStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := BatchStats{
+		MaxBatch:  b.maxBatch,
+		TimeoutMs: float64(b.timeout.Microseconds()) / 1000,
+		Batches:   b.batches,
+		Requests:  b.total,
+		LastSize:  b.lastSize,
+		Sizes:     make(map[int]int64),
+	}
+	if b.batches > 0 {
+		stats.MeanSize = float64(b.total) / float64(b.batches)
+	}
+	for size, count := range b.sizes {
+		if count > 0 {
+			stats.Sizes[size] = count
+		}
+	}
+	return stats
+}
+
+// run собирает запросы в батчи до закрытия очереди
+func (b *Batcher) run() {
+	for {
+		var batch []*batchRequest
+		select {
+		case request := <-b.requests:
+			batch = append(batch, request)
+		case <-b.done:
+			return
+		}
+
+		timer := time.NewTimer(b.timeout)
+	collect:
+		for len(batch) < b.maxBatch {
+			select {
+			case request := <-b.requests:
+				batch = append(batch, request)
+			case <-timer.C:
+				break collect
+			case <-b.done:
+				break collect
+			}
+		}
+		timer.Stop()
+
+		b.execute(batch)
+	}
+}
+
+// execute выполняет один батч и раздает результаты
+func (b *Batcher) execute(batch []*batchRequest) {
+	network, source := b.model.Network(), b.model.Source()
+	if network == nil {
+		for _, request := range batch {
+			request.result <- batchResult{err: errNoModel}
+		}
+		return
+	}
+
+	inputs := make([][]float64, len(batch))
+	for i, request := range batch {
+		inputs[i] = request.input
+	}
+	outputs, err := predictBatch(network, inputs)
+	if err != nil {
+		log.Printf("Батч из %d входов модели %s: %v", len(batch), source, err)
+		for _, request := range batch {
+			request.result <- batchResult{err: err}
+		}
+		return
+	}
+	for i, request := range batch {
+		request.result <- batchResult{output: outputs[i], source: source}
+	}
+
+	b.mu.Lock()
+	b.batches++
+	b.total += int64(len(batch))
+	b.sizes[len(batch)]++
+	b.lastSize = len(batch)
+	b.mu.Unlock()
+}
+
+// predictBatch выполняет батч на сети. Паника сети превращается в ошибку:
+// батчи выполняются в горутине очереди, и паника остановила бы весь сервер
+func predictBatch(network *Network, inputs [][]float64) (outputs [][]float64, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("ошибка предсказания: %v", p)
+		}
+	}()
+	return network.PredictBatch(inputs), nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Command подкоманда программы
+type Command struct {
+	Description string
+	Run         func(args []string) error
+}
+
+// commands подкоманды, первый аргумент командной строки.
+// Без подкоманды программа обучает сеть и открывает окно рисования
+var commands = map[string]Command{
+	"augment": {
+		Description: "сохранение примеров аугментированных изображений",
+		Run:         runAugmentPreview,
+	},
+	"gui": {
+		Description: "окно предсказаний для сохраненной модели без обучения",
+		Run:         runGUI,
+	},
+	"convert": {
+		Description: "конвертация набора данных между форматами",
+		Run:         runConvert,
+	},
+	"predict": {
+		Description: "классификация изображений из файлов, каталогов и наборов данных",
+		Run:         runPredict,
+	},
+	"serve": {
+		Description: "HTTP- и gRPC-сервер предсказаний",
+		Run:         runServe,
+	},
+	"weights": {
+		Description: "сохранение весов первого слоя в виде изображений",
+		Run:         runWeights,
+	},
+}
+
+// printCommands выводит список подкоманд
+func printCommands() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "Подкоманды:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].Description)
+	}
+}
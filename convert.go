@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+)
+
+// runConvert конвертирует набор данных между форматами с возможной подвыборкой
+func runConvert(args []string) error {
+	flags := flag.NewFlagSet("convert", flag.ExitOnError)
+	from := flags.String("from", "", "исходный набор <формат>:<путь>")
+	to := flags.String("to", "", "результирующий набор <формат>:<путь>")
+	limit := flags.Int("n", 0, "размер подвыборки (0 — весь набор)")
+	stratified := flags.Bool("stratified", false, "сохранять доли классов в подвыборке")
+	seed := flags.Int64("seed", 1, "зерно генератора для воспроизводимой подвыборки")
+	strokeVariants := flags.Int("stroke-variants", 0, "для quickdraw: сколько вариантов с аугментацией штрихов добавить к каждому рисунку")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Использование: mnist convert -from <формат>:<путь> -to <формат>:<путь> [флаги]")
+		flags.PrintDefaults()
+		fmt.Fprintln(os.Stderr, "Форматы:")
+		for _, name := range datasetFormatNames() {
+			fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, datasetFormats[name].Description)
+		}
+	}
+	flags.Parse(args)
+
+	if *from == "" || *to == "" {
+		flags.Usage()
+		return fmt.Errorf("необходимо указать -from и -to")
+	}
+
+	var dataset *Dataset
+	var err error
+	if path, ok := strings.CutPrefix(*from, "quickdraw:"); ok && *strokeVariants > 0 {
+		dataset, err = LoadQuickDrawAugmented(path, *strokeVariants, rand.New(rand.NewSource(*seed)))
+	} else {
+		dataset, err = LoadDataset(*from)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Загружено %d изображений из %s\n", dataset.Len(), *from)
+
+	if *limit > 0 {
+		dataset = dataset.Sample(*limit, *stratified, rand.New(rand.NewSource(*seed)))
+		fmt.Printf("Выбрано %d изображений\n", dataset.Len())
+	}
+
+	if err := SaveDataset(dataset, *to); err != nil {
+		return err
+	}
+	fmt.Printf("Сохранено %d изображений в %s\n", dataset.Len(), *to)
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Dataset набор изображений 28x28 с метками
+type Dataset struct {
+	Images [][]float64
+	Labels []int
+}
+
+// Len возвращает количество примеров
+func (d *Dataset) Len() int {
+	return len(d.Images)
+}
+
+// Append добавляет примеры другого набора
+func (d *Dataset) Append(other *Dataset) {
+	d.Images = append(d.Images, other.Images...)
+	d.Labels = append(d.Labels, other.Labels...)
+}
+
+// Split делит набор случайным образом: доля fraction уходит во вторую часть
+func (d *Dataset) Split(fraction float64) (*Dataset, *Dataset) {
+	indices := rand.Perm(d.Len())
+	cut := d.Len() - int(float64(d.Len())*fraction)
+
+	first, second := &Dataset{}, &Dataset{}
+	for i, idx := range indices {
+		target := first
+		if i >= cut {
+			target = second
+		}
+		target.Images = append(target.Images, d.Images[idx])
+		target.Labels = append(target.Labels, d.Labels[idx])
+	}
+
+	return first, second
+}
+
+// imageExtensions поддерживаемые форматы изображений
+var imageExtensions = map[string]bool{
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".gif":  true,
+}
+
+// LoadImageFolder загружает изображения из каталогов вида root/<метка>/*.png.
+// Каждое изображение приводится к формату MNIST (см. LoadImageFile)
+func LoadImageFolder(root string) (*Dataset, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	dataset := &Dataset{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		label, err := strconv.Atoi(entry.Name())
+		if err != nil || label < 0 || label > 9 {
+			continue
+		}
+
+		files, err := listImages(filepath.Join(root, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			image, err := LoadImageFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			dataset.Images = append(dataset.Images, image)
+			dataset.Labels = append(dataset.Labels, label)
+		}
+	}
+
+	if dataset.Len() == 0 {
+		return nil, fmt.Errorf("в каталоге %s не найдено изображений", root)
+	}
+
+	return dataset, nil
+}
+
+// listImages рекурсивно ищет файлы изображений в каталоге
+func listImages(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && imageExtensions[strings.ToLower(filepath.Ext(path))] {
+			files = append(files, path)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// Sample выбирает n случайных примеров. При stratified доли классов
+// в выборке совпадают с долями в исходном наборе
+func (d *Dataset) Sample(n int, stratified bool, rng *rand.Rand) *Dataset {
+	if n <= 0 || n >= d.Len() {
+		n = d.Len()
+	}
+
+	var selected []int
+	if stratified {
+		byClass := make(map[int][]int)
+		var classes []int
+		for i, label := range d.Labels {
+			if _, ok := byClass[label]; !ok {
+				classes = append(classes, label)
+			}
+			byClass[label] = append(byClass[label], i)
+		}
+		sort.Ints(classes)
+
+		// Количество на класс пропорционально его размеру, остаток
+		// распределяется по классам по очереди
+		quota := make(map[int]int)
+		total := 0
+		for _, class := range classes {
+			quota[class] = n * len(byClass[class]) / d.Len()
+			total += quota[class]
+		}
+		for i := 0; total < n; i++ {
+			class := classes[i%len(classes)]
+			if quota[class] < len(byClass[class]) {
+				quota[class]++
+				total++
+			}
+		}
+
+		for _, class := range classes {
+			indices := byClass[class]
+			rng.Shuffle(len(indices), func(i, j int) {
+				indices[i], indices[j] = indices[j], indices[i]
+			})
+			selected = append(selected, indices[:quota[class]]...)
+		}
+		rng.Shuffle(len(selected), func(i, j int) {
+			selected[i], selected[j] = selected[j], selected[i]
+		})
+	} else {
+		selected = rng.Perm(d.Len())[:n]
+	}
+
+	sample := &Dataset{}
+	for _, idx := range selected {
+		sample.Images = append(sample.Images, d.Images[idx])
+		sample.Labels = append(sample.Labels, d.Labels[idx])
+	}
+
+	return sample
+}
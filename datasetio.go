@@ -0,0 +1,450 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DatasetFormat формат хранения набора данных
+type DatasetFormat struct {
+	Description string
+	Load        func(path string) (*Dataset, error)
+	Save        func(d *Dataset, path string) error
+}
+
+// datasetFormats поддерживаемые форматы, путь задается как <формат>:<путь>
+var datasetFormats = map[string]DatasetFormat{
+	"bin": {
+		Description: "сырые байты <путь>-images.bin и <путь>-labels.bin",
+		Load:        LoadBinDataset,
+		Save:        SaveBinDataset,
+	},
+	"idx": {
+		Description: "формат IDX оригинального MNIST: <путь>-images-idx3-ubyte и <путь>-labels-idx1-ubyte",
+		Load:        LoadIDXDataset,
+		Save:        SaveIDXDataset,
+	},
+	"csv": {
+		Description: "CSV-файл: label,pixel0..pixel783 (значения 0..255)",
+		Load:        LoadCSVDataset,
+		Save:        SaveCSVDataset,
+	},
+	"folder": {
+		Description: "каталог изображений <путь>/<метка>/*.png",
+		Load:        LoadImageFolder,
+		Save:        SaveImageFolder,
+	},
+	"npy": {
+		Description: "массивы NumPy <путь>-images.npy (N, 28, 28) и <путь>-labels.npy (N,)",
+		Load:        LoadNPYDataset,
+		Save:        SaveNPYDataset,
+	},
+	"quickdraw": {
+		Description: "штрихи в NDJSON формата Google QuickDraw, подпись - цифра (только чтение)",
+		Load:        LoadQuickDrawDataset,
+	},
+}
+
+// parseDatasetSpec разбирает строку вида <формат>:<путь>
+func parseDatasetSpec(spec string) (DatasetFormat, string, error) {
+	name, path, ok := strings.Cut(spec, ":")
+	format, known := datasetFormats[name]
+	if !ok || !known || path == "" {
+		return DatasetFormat{}, "", fmt.Errorf("неверный набор данных %q, ожидается <формат>:<путь>, форматы: %s",
+			spec, strings.Join(datasetFormatNames(), ", "))
+	}
+	return format, path, nil
+}
+
+// datasetFormatNames возвращает отсортированные имена форматов
+func datasetFormatNames() []string {
+	names := make([]string, 0, len(datasetFormats))
+	for name := range datasetFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadDataset загружает набор по строке вида <формат>:<путь>
+func LoadDataset(spec string) (*Dataset, error) {
+	format, path, err := parseDatasetSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	return format.Load(path)
+}
+
+// SaveDataset сохраняет набор по строке вида <формат>:<путь>
+func SaveDataset(d *Dataset, spec string) error {
+	format, path, err := parseDatasetSpec(spec)
+	if err != nil {
+		return err
+	}
+	if format.Save == nil {
+		return fmt.Errorf("формат набора %q поддерживает только чтение", spec)
+	}
+	return format.Save(d, path)
+}
+
+const (
+	idxImagesMagic = 0x00000803 // unsigned byte, 3 измерения
+	idxLabelsMagic = 0x00000801 // unsigned byte, 1 измерение
+)
+
+// LoadIDXDataset загружает набор в формате IDX
+func LoadIDXDataset(prefix string) (*Dataset, error) {
+	imagesData, err := readIDX(prefix+"-images-idx3-ubyte", idxImagesMagic)
+	if err != nil {
+		return nil, err
+	}
+
+	labelsData, err := readIDX(prefix+"-labels-idx1-ubyte", idxLabelsMagic)
+	if err != nil {
+		return nil, err
+	}
+
+	return datasetFromBytes(imagesData, labelsData)
+}
+
+// SaveIDXDataset сохраняет набор в формате IDX
+func SaveIDXDataset(d *Dataset, prefix string) error {
+	imagesData, labelsData, err := datasetToBytes(d)
+	if err != nil {
+		return err
+	}
+
+	err = writeIDX(prefix+"-images-idx3-ubyte", idxImagesMagic,
+		[]uint32{uint32(d.Len()), ImageSide, ImageSide}, imagesData)
+	if err != nil {
+		return err
+	}
+	return writeIDX(prefix+"-labels-idx1-ubyte", idxLabelsMagic, []uint32{uint32(d.Len())}, labelsData)
+}
+
+// readIDX читает IDX-файл и возвращает данные после заголовка
+func readIDX(filename string, magic uint32) ([]byte, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	dims := int(magic & 0xff)
+	headerSize := 4 + 4*dims
+	if len(data) < headerSize || binary.BigEndian.Uint32(data) != magic {
+		return nil, fmt.Errorf("%s: неверный заголовок IDX", filename)
+	}
+
+	return data[headerSize:], nil
+}
+
+// writeIDX записывает IDX-файл
+func writeIDX(filename string, magic uint32, dims []uint32, data []byte) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	header := append([]uint32{magic}, dims...)
+	if err := binary.Write(file, binary.BigEndian, header); err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+	return err
+}
+
+// LoadCSVDataset загружает набор из CSV (первый столбец — метка)
+func LoadCSVDataset(filename string) (*Dataset, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(bufio.NewReader(file))
+	reader.FieldsPerRecord = ImagePixels + 1
+	reader.ReuseRecord = true
+
+	dataset := &Dataset{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		label, err := strconv.Atoi(record[0])
+		if err != nil {
+			// Пропускаем строку заголовка
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("%s:%d: неверная метка %q", filename, line, record[0])
+		}
+		if label < 0 || label > 9 {
+			return nil, fmt.Errorf("%s:%d: метка %d вне диапазона 0..9", filename, line, label)
+		}
+
+		image := make([]float64, ImagePixels)
+		for j, field := range record[1:] {
+			value, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: неверное значение пикселя %q", filename, line, field)
+			}
+			image[j] = value / PixelMax
+		}
+
+		dataset.Images = append(dataset.Images, image)
+		dataset.Labels = append(dataset.Labels, label)
+	}
+
+	return dataset, nil
+}
+
+// SaveCSVDataset сохраняет набор в CSV
+func SaveCSVDataset(d *Dataset, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+
+	record := make([]string, ImagePixels+1)
+	record[0] = "label"
+	for j := 0; j < ImagePixels; j++ {
+		record[j+1] = fmt.Sprintf("pixel%d", j)
+	}
+	if err := writer.Write(record); err != nil {
+		return err
+	}
+
+	for i, image := range d.Images {
+		record[0] = strconv.Itoa(d.Labels[i])
+		for j, value := range image {
+			record[j+1] = strconv.Itoa(int(pixelToByte(value)))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// SaveImageFolder сохраняет набор как каталог PNG-файлов <root>/<метка>/<номер>.png
+func SaveImageFolder(d *Dataset, root string) error {
+	for i, image := range d.Images {
+		dir := filepath.Join(root, strconv.Itoa(d.Labels[i]))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+
+		filename := filepath.Join(dir, fmt.Sprintf("%05d.png", i))
+		if err := SaveImageAsPNG(image, filename, d.Labels[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadNPYDataset загружает массивы NumPy с изображениями (целые 0..255 или float 0..1) и метками
+func LoadNPYDataset(prefix string) (*Dataset, error) {
+	images, imagesShape, err := readNPY(prefix + "-images.npy")
+	if err != nil {
+		return nil, err
+	}
+
+	labels, labelsShape, err := readNPY(prefix + "-labels.npy")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(labelsShape) != 1 || len(imagesShape) == 0 || imagesShape[0] != labelsShape[0] ||
+		len(images) != labelsShape[0]*ImagePixels {
+		return nil, fmt.Errorf("%s: неподдерживаемая форма массивов %v и %v", prefix, imagesShape, labelsShape)
+	}
+
+	dataset := &Dataset{
+		Images: make([][]float64, labelsShape[0]),
+		Labels: make([]int, labelsShape[0]),
+	}
+	for i := range dataset.Images {
+		dataset.Images[i] = images[i*ImagePixels : (i+1)*ImagePixels]
+		label := labels[i]
+		if label != math.Trunc(label) || label < 0 || label > 9 {
+			return nil, fmt.Errorf("%s-labels.npy: метка %g с индексом %d вне диапазона 0..9", prefix, label, i)
+		}
+		dataset.Labels[i] = int(label)
+	}
+
+	return dataset, nil
+}
+
+// SaveNPYDataset сохраняет набор в виде массивов NumPy uint8
+func SaveNPYDataset(d *Dataset, prefix string) error {
+	imagesData, labelsData, err := datasetToBytes(d)
+	if err != nil {
+		return err
+	}
+
+	if err := writeNPY(prefix+"-images.npy", []int{d.Len(), ImageSide, ImageSide}, imagesData); err != nil {
+		return err
+	}
+	return writeNPY(prefix+"-labels.npy", []int{d.Len()}, labelsData)
+}
+
+var (
+	npyMagic      = []byte("\x93NUMPY")
+	npyDescrRe    = regexp.MustCompile(`'descr':\s*'([^']+)'`)
+	npyFortranRe  = regexp.MustCompile(`'fortran_order':\s*(True|False)`)
+	npyShapeRe    = regexp.MustCompile(`'shape':\s*\(([^)]*)\)`)
+	npyItemSizeOf = map[string]int{
+		"|u1": 1, "|i1": 1, "<u2": 2, "<i2": 2, "<u4": 4, "<i4": 4, "<u8": 8, "<i8": 8,
+		"<f4": 4, "<f8": 8,
+	}
+)
+
+// readNPY читает массив NumPy. Целые значения многомерных массивов (изображения)
+// переводятся из 0..255 в диапазон 0..1, одномерные (метки) возвращаются как есть
+func readNPY(filename string) ([]float64, []int, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(data) < 10 || !bytes.HasPrefix(data, npyMagic) {
+		return nil, nil, fmt.Errorf("%s: не является файлом .npy", filename)
+	}
+
+	var headerLen, offset int
+	switch data[6] {
+	case 1:
+		headerLen, offset = int(binary.LittleEndian.Uint16(data[8:])), 10
+	case 2, 3:
+		if len(data) < 12 {
+			return nil, nil, fmt.Errorf("%s: поврежденный заголовок", filename)
+		}
+		headerLen, offset = int(binary.LittleEndian.Uint32(data[8:])), 12
+	default:
+		return nil, nil, fmt.Errorf("%s: неподдерживаемая версия .npy %d", filename, data[6])
+	}
+	if len(data) < offset+headerLen {
+		return nil, nil, fmt.Errorf("%s: поврежденный заголовок", filename)
+	}
+	header := string(data[offset : offset+headerLen])
+	body := data[offset+headerLen:]
+
+	descr := npyDescrRe.FindStringSubmatch(header)
+	shapeMatch := npyShapeRe.FindStringSubmatch(header)
+	if descr == nil || shapeMatch == nil {
+		return nil, nil, fmt.Errorf("%s: неверный заголовок %q", filename, header)
+	}
+	if fortran := npyFortranRe.FindStringSubmatch(header); fortran != nil && fortran[1] == "True" {
+		return nil, nil, fmt.Errorf("%s: порядок fortran_order не поддерживается", filename)
+	}
+
+	itemSize, ok := npyItemSizeOf[descr[1]]
+	if !ok {
+		return nil, nil, fmt.Errorf("%s: неподдерживаемый тип %s", filename, descr[1])
+	}
+
+	var shape []int
+	count := 1
+	for _, field := range strings.Split(shapeMatch[1], ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		dim, err := strconv.Atoi(field)
+		if err != nil || dim < 0 {
+			return nil, nil, fmt.Errorf("%s: неверная форма %q", filename, shapeMatch[1])
+		}
+		// Сравнение до умножения: огромные размеры из заголовка не переполнят count
+		if dim > 0 && count > len(body)/itemSize/dim {
+			return nil, nil, fmt.Errorf("%s: файл обрезан", filename)
+		}
+		shape = append(shape, dim)
+		count *= dim
+	}
+
+	values := make([]float64, count)
+	for i := range values {
+		item := body[i*itemSize:]
+		switch descr[1] {
+		case "|u1":
+			values[i] = float64(item[0])
+		case "|i1":
+			values[i] = float64(int8(item[0]))
+		case "<u2":
+			values[i] = float64(binary.LittleEndian.Uint16(item))
+		case "<i2":
+			values[i] = float64(int16(binary.LittleEndian.Uint16(item)))
+		case "<u4":
+			values[i] = float64(binary.LittleEndian.Uint32(item))
+		case "<i4":
+			values[i] = float64(int32(binary.LittleEndian.Uint32(item)))
+		case "<u8":
+			values[i] = float64(binary.LittleEndian.Uint64(item))
+		case "<i8":
+			values[i] = float64(int64(binary.LittleEndian.Uint64(item)))
+		case "<f4":
+			values[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(item)))
+		case "<f8":
+			values[i] = math.Float64frombits(binary.LittleEndian.Uint64(item))
+		}
+		if descr[1][1] != 'f' && len(shape) > 1 {
+			values[i] /= PixelMax
+		}
+	}
+
+	return values, shape, nil
+}
+
+// writeNPY записывает массив uint8 в формате .npy версии 1.0
+func writeNPY(filename string, shape []int, data []byte) error {
+	dims := make([]string, len(shape))
+	for i, dim := range shape {
+		dims[i] = strconv.Itoa(dim)
+	}
+	shapeText := strings.Join(dims, ", ")
+	if len(shape) == 1 {
+		shapeText += ","
+	}
+
+	header := fmt.Sprintf("{'descr': '|u1', 'fortran_order': False, 'shape': (%s), }", shapeText)
+	// Заголовок дополняется пробелами до кратности 64 байтам и завершается переводом строки
+	total := len(npyMagic) + 4 + len(header) + 1
+	header += strings.Repeat(" ", (64-total%64)%64) + "\n"
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	writer.Write(npyMagic)
+	writer.Write([]byte{1, 0})
+	binary.Write(writer, binary.LittleEndian, uint16(len(header)))
+	writer.WriteString(header)
+	writer.Write(data)
+	return writer.Flush()
+}
@@ -5,36 +5,86 @@ import (
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/widget"
+	"image"
 	"image/color"
+	"time"
 )
 
 const (
 	GridSize  = 28
 	PixelSize = 20
+
+	// rasterCellSize клетки меньше этого размера рисуются одним растром, а не прямоугольниками
+	rasterCellSize = 4
 )
 
 type DrawGrid struct {
 	widget.BaseWidget
-	Data      [][]float64
+	Data      [][]float64 // Значения клеток 0..1, Data[y][x]
+	Cols      int
+	Rows      int
+	CellSize  float32 // Размер клетки на экране
 	mouseDown bool
+
+	BrushRadius    float64 // Радиус кисти в клетках
+	BrushIntensity float64 // Интенсивность одного мазка 0..1
+	EraseMode      bool    // Режим ластика
+	ReadOnly       bool    // Только отображение, рисование мышью отключено
+
+	strokes   []*Stroke // История мазков для отмены и повтора
+	undone    []*Stroke // Отмененные мазки, которые можно вернуть
+	current   *Stroke   // Мазок, который рисуется сейчас
+	replaying bool
+	base      []float64 // Изображение под мазками (например, открытое из файла)
+	mnistBase bool      // Основа уже приведена к формату MNIST, повторная нормализация не нужна
+	started   time.Time // Начало первого мазка, от него отсчитывается время точек
+
+	pending image.Rectangle // Клетки, измененные после последнего вызова changed
+	dirty   image.Rectangle // Клетки, которые отрисовщик еще не обновил
+
+	// LastChange клетки, измененные последним изменением рисунка. Читается в OnChanged,
+	// чтобы пересчитывать только затронутую часть
+	LastChange image.Rectangle
+
+	// Overlay карта значимости -1..1 поверх рисунка: положительные значения красные,
+	// отрицательные синие. Сбрасывается при любом изменении рисунка
+	Overlay []float64
+
+	OnChanged func() // Вызывается после каждого изменения рисунка
 }
 
+// NewDrawGrid создает холст 28x28 для одной цифры
 func NewDrawGrid() *DrawGrid {
+	return NewDrawGridSize(GridSize, GridSize, PixelSize)
+}
+
+// NewDrawGridSize создает холст из cols x rows клеток размером cellSize
+func NewDrawGridSize(cols, rows int, cellSize float32) *DrawGrid {
 	d := &DrawGrid{
-		Data: make([][]float64, GridSize),
+		Data:           make([][]float64, rows),
+		Cols:           cols,
+		Rows:           rows,
+		CellSize:       cellSize,
+		BrushRadius:    1.2,
+		BrushIntensity: 0.6,
 	}
 	for i := range d.Data {
-		d.Data[i] = make([]float64, GridSize)
+		d.Data[i] = make([]float64, cols)
 	}
 	d.ExtendBaseWidget(d)
 	return d
 }
 
 func (d *DrawGrid) CreateRenderer() fyne.WidgetRenderer {
-	objects := make([]fyne.CanvasObject, 0, GridSize*GridSize)
+	d.dirty = d.bounds()
+	if d.CellSize < rasterCellSize {
+		return newRasterGridRenderer(d)
+	}
+
+	objects := make([]fyne.CanvasObject, 0, d.Cols*d.Rows)
 
-	for y := 0; y < GridSize; y++ {
-		for x := 0; x < GridSize; x++ {
+	for y := 0; y < d.Rows; y++ {
+		for x := 0; x < d.Cols; x++ {
 			rect := canvas.NewRectangle(color.White)
 			rect.StrokeColor = color.RGBA{200, 200, 200, 255}
 			rect.StrokeWidth = 1
@@ -51,33 +101,30 @@ type drawGridRenderer struct {
 }
 
 func (r *drawGridRenderer) Layout(size fyne.Size) {
-	for y := 0; y < GridSize; y++ {
-		for x := 0; x < GridSize; x++ {
-			idx := y*GridSize + x
-			px := float32(x * PixelSize)
-			py := float32(y * PixelSize)
-			r.rects[idx].Resize(fyne.NewSize(PixelSize, PixelSize))
+	cell := r.grid.CellSize
+	for y := 0; y < r.grid.Rows; y++ {
+		for x := 0; x < r.grid.Cols; x++ {
+			idx := y*r.grid.Cols + x
+			px := float32(x) * cell
+			py := float32(y) * cell
+			r.rects[idx].Resize(fyne.NewSize(cell, cell))
 			r.rects[idx].Move(fyne.NewPos(px, py))
 		}
 	}
 }
 
 func (r *drawGridRenderer) MinSize() fyne.Size {
-	return fyne.NewSize(GridSize*PixelSize, GridSize*PixelSize)
+	return fyne.NewSize(float32(r.grid.Cols)*r.grid.CellSize, float32(r.grid.Rows)*r.grid.CellSize)
 }
 
 func (r *drawGridRenderer) Refresh() {
-	for y := 0; y < GridSize; y++ {
-		for x := 0; x < GridSize; x++ {
-			idx := y*GridSize + x
+	area := r.grid.takeDirty()
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			idx := y*r.grid.Cols + x
 			rect := r.rects[idx].(*canvas.Rectangle)
 
-			if r.grid.Data[y][x] == 1 {
-				rect.FillColor = color.Black
-			} else {
-				rect.FillColor = color.White
-			}
-
+			rect.FillColor = r.grid.cellColor(x, y)
 			rect.Refresh()
 		}
 	}
@@ -86,39 +133,61 @@ func (r *drawGridRenderer) Refresh() {
 func (r *drawGridRenderer) Objects() []fyne.CanvasObject { return r.rects }
 func (r *drawGridRenderer) Destroy()                     {}
 
-func (d *DrawGrid) pointToPixel(p fyne.Position) (int, int, bool) {
-	x := int(p.X) / PixelSize
-	y := int(p.Y) / PixelSize
-	if x < 0 || x >= GridSize || y < 0 || y >= GridSize {
-		return 0, 0, false
-	}
-	return x, y, true
+// rasterGridRenderer рисует холст высокого разрешения одним изображением.
+// Изображение хранится между кадрами, перекрашиваются только измененные клетки
+type rasterGridRenderer struct {
+	grid   *DrawGrid
+	raster *canvas.Raster
+	img    *image.RGBA
 }
 
-func (d *DrawGrid) mouseDraw(p fyne.Position) {
-	x, y, ok := d.pointToPixel(p)
-	if !ok {
-		return
-	}
-	d.Data[y][x] = 1
-	if y+1 < GridSize && x+1 < GridSize {
-		d.Data[y+1][x+1] = 1
-	}
+func newRasterGridRenderer(d *DrawGrid) *rasterGridRenderer {
+	r := &rasterGridRenderer{grid: d, img: image.NewRGBA(d.bounds())}
+	r.raster = canvas.NewRaster(r.generate)
+	r.raster.ScaleMode = canvas.ImageScaleSmooth
+	return r
+}
 
-	if y+1 < GridSize {
-		d.Data[y+1][x] = 1
+func (r *rasterGridRenderer) generate(int, int) image.Image {
+	area := r.grid.takeDirty()
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			r.img.Set(x, y, r.grid.cellColor(x, y))
+		}
 	}
+	return r.img
+}
 
-	if x+1 < GridSize {
-		d.Data[y][x+1] = 1
+func (r *rasterGridRenderer) Layout(size fyne.Size) {
+	r.raster.Resize(size)
+}
+
+func (r *rasterGridRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(float32(r.grid.Cols)*r.grid.CellSize, float32(r.grid.Rows)*r.grid.CellSize)
+}
+
+func (r *rasterGridRenderer) Refresh()                     { r.raster.Refresh() }
+func (r *rasterGridRenderer) Objects() []fyne.CanvasObject { return []fyne.CanvasObject{r.raster} }
+func (r *rasterGridRenderer) Destroy()                     {}
+
+// cellColor цвет клетки: чем больше значение, тем темнее клетка
+func (d *DrawGrid) cellColor(x, y int) color.Color {
+	if d.Overlay != nil {
+		return overlayColor(d.Data[y][x], d.Overlay[y*d.Cols+x])
 	}
+	return color.Gray{Y: uint8(255 * (1 - d.Data[y][x]))}
+}
+
+// pointToCell переводит позицию мыши в дробные координаты клеток
+func (d *DrawGrid) pointToCell(p fyne.Position) (float64, float64) {
+	return float64(p.X / d.CellSize), float64(p.Y / d.CellSize)
 }
 
 func (d *DrawGrid) getDataForPredict() []float64 {
-	result := make([]float64, GridSize*GridSize)
+	result := make([]float64, d.Cols*d.Rows)
 	index := 0
-	for y := 0; y < GridSize; y++ {
-		for x := 0; x < GridSize; x++ {
+	for y := 0; y < d.Rows; y++ {
+		for x := 0; x < d.Cols; x++ {
 			result[index] = d.Data[y][x]
 			index++
 		}
@@ -127,23 +196,44 @@ func (d *DrawGrid) getDataForPredict() []float64 {
 }
 
 func (d *DrawGrid) MouseDown(ev *desktop.MouseEvent) {
+	if d.replaying || d.ReadOnly {
+		return
+	}
+
 	d.mouseDown = true
-	d.mouseDraw(ev.Position)
+	d.current = &Stroke{
+		Radius:    d.BrushRadius,
+		Intensity: d.BrushIntensity,
+		Erase:     d.EraseMode,
+	}
+	d.addPoint(ev.Position)
+	d.drawStroke(d.current, 0)
+	d.changed()
+	d.Refresh()
 }
 
 func (d *DrawGrid) MouseUp(ev *desktop.MouseEvent) {
 	d.mouseDown = false
+	if d.current == nil {
+		return
+	}
+
+	d.strokes = append(d.strokes, d.current)
+	d.undone = nil
+	d.current = nil
 }
 
 func (d *DrawGrid) MouseMoved(ev *desktop.MouseEvent) {
-	if d.mouseDown {
+	if d.mouseDown && d.current != nil {
+		d.addPoint(ev.Position)
+		d.drawStroke(d.current, len(d.current.Points)-1)
+		d.changed()
+
 		go func() {
 			fyne.DoAndWait(func() {
 				d.Refresh()
 			})
 		}()
-
-		d.mouseDraw(ev.Position)
 	}
 }
 
@@ -151,11 +241,111 @@ func (d *DrawGrid) MouseIn(*desktop.MouseEvent) {}
 
 func (d *DrawGrid) MouseOut() {}
 
+// Clear очищает рисунок вместе с историей мазков
 func (d *DrawGrid) Clear() {
-	for y := 0; y < GridSize; y++ {
-		for x := 0; x < GridSize; x++ {
-			d.Data[y][x] = 0
+	d.SetImage(nil)
+}
+
+// SetImage заменяет рисунок изображением размером с холст (nil — пустой холст).
+// Изображение становится основой, поверх которой рисуются и отменяются мазки
+func (d *DrawGrid) SetImage(pix []float64) {
+	d.setImage(pix, false)
+}
+
+// SetMNISTImage заменяет рисунок изображением, уже приведенным к формату MNIST
+// (см. NormalizeToMNIST). Мазки поверх него рисуются в той же системе координат
+func (d *DrawGrid) SetMNISTImage(pix []float64) {
+	d.setImage(pix, true)
+}
+
+// MNISTBase сообщает, что рисунок лежит на основе в формате MNIST и не требует нормализации
+func (d *DrawGrid) MNISTBase() bool {
+	return d.base != nil && d.mnistBase
+}
+
+func (d *DrawGrid) setImage(pix []float64, mnist bool) {
+	d.base = pix
+	d.mnistBase = mnist
+	d.strokes = nil
+	d.undone = nil
+	d.started = time.Time{}
+	d.resetData()
+	d.changed()
+	d.Refresh()
+}
+
+// SetImageRect как SetImage, но пересчитывает только клетки r. Остальные клетки pix
+// должны совпадать с текущим рисунком, например, когда pix — обновляемое уменьшенное изображение
+func (d *DrawGrid) SetImageRect(pix []float64, r image.Rectangle) {
+	r = r.Intersect(d.bounds())
+	d.base = pix
+	d.mnistBase = false
+	d.strokes = nil
+	d.undone = nil
+	d.started = time.Time{}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			d.Data[y][x] = pix[y*d.Cols+x]
 		}
 	}
+	d.touch(r)
+	d.changed()
 	d.Refresh()
 }
+
+// SetOverlay показывает карту значимости поверх рисунка (nil — убрать)
+func (d *DrawGrid) SetOverlay(values []float64) {
+	d.Overlay = values
+	d.dirty = d.bounds()
+	d.Refresh()
+}
+
+// resetData возвращает холст к основе без мазков
+func (d *DrawGrid) resetData() {
+	d.touch(d.bounds())
+	for y := 0; y < d.Rows; y++ {
+		for x := 0; x < d.Cols; x++ {
+			d.Data[y][x] = 0
+			if d.base != nil {
+				d.Data[y][x] = d.base[y*d.Cols+x]
+			}
+		}
+	}
+}
+
+func (d *DrawGrid) changed() {
+	if d.Overlay != nil {
+		d.Overlay = nil
+		d.touch(d.bounds())
+	}
+	d.LastChange = d.pending
+	d.dirty = d.dirty.Union(d.pending)
+	d.pending = image.Rectangle{}
+	if d.OnChanged != nil {
+		d.OnChanged()
+	}
+}
+
+// bounds рамка всего холста в клетках
+func (d *DrawGrid) bounds() image.Rectangle {
+	return image.Rect(0, 0, d.Cols, d.Rows)
+}
+
+// touch отмечает клетки r измененными
+func (d *DrawGrid) touch(r image.Rectangle) {
+	d.pending = d.pending.Union(r)
+}
+
+// flushPending передает отрисовщику клетки, измененные после последнего changed,
+// не вызывая OnChanged. Нужен, когда рисунок показывается по шагам, как при воспроизведении
+func (d *DrawGrid) flushPending() {
+	d.dirty = d.dirty.Union(d.pending)
+	d.pending = image.Rectangle{}
+}
+
+// takeDirty возвращает клетки, которые нужно перерисовать, и сбрасывает их
+func (d *DrawGrid) takeDirty() image.Rectangle {
+	area := d.dirty.Intersect(d.bounds())
+	d.dirty = image.Rectangle{}
+	return area
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+)
+
+// Методы объяснения предсказаний
+const (
+	ExplainGradient   = "gradient"   // Модуль градиента по входу
+	ExplainSmoothGrad = "smoothgrad" // Градиент, усредненный по зашумленным копиям входа
+	ExplainIntegrated = "ig"         // Integrated Gradients от черного изображения
+)
+
+// ExplainMethods названия методов объяснения для интерфейса
+var ExplainMethods = map[string]string{
+	ExplainGradient:   "Градиент",
+	ExplainSmoothGrad: "SmoothGrad",
+	ExplainIntegrated: "Integrated Gradients",
+}
+
+// Explainer вычисляет карты значимости пикселей входа для выбранного класса.
+// Градиенты считаются штатным Backward на собственной копии сети,
+// поэтому исходную сеть можно одновременно использовать для Predict
+type Explainer struct {
+	Samples int     // Количество зашумленных копий для SmoothGrad
+	Noise   float64 // Стандартное отклонение шума SmoothGrad
+	Steps   int     // Количество шагов интегрирования Integrated Gradients
+
+	network *Network
+	rng     *rand.Rand
+}
+
+// NewExplainer создает объяснитель для копии сети
+func NewExplainer(network *Network) *Explainer {
+	return &Explainer{
+		Samples: 25,
+		Noise:   0.15,
+		Steps:   32,
+		network: network.Clone(),
+		rng:     rand.New(rand.NewSource(1)),
+	}
+}
+
+// Explain вычисляет карту значимости методом method для изображения 28x28
+func (e *Explainer) Explain(method string, input []float64, class int) ([]float64, error) {
+	switch method {
+	case ExplainGradient:
+		return e.Saliency(input, class), nil
+	case ExplainSmoothGrad:
+		return e.SmoothGrad(input, class), nil
+	case ExplainIntegrated:
+		return e.IntegratedGradients(input, class), nil
+	}
+	return nil, fmt.Errorf("неизвестный метод объяснения %q", method)
+}
+
+// Gradient возвращает градиент логарифма вероятности класса по пикселям входа 28x28
+func (e *Explainer) Gradient(input []float64, class int) []float64 {
+	x := e.network.Transforms.Apply(input)
+	e.network.Backward(x, class)
+
+	// Delta первого слоя - производная потери -log p по его взвешенной сумме,
+	// градиент по входу получается умножением на транспонированные веса
+	first := e.network.Layers[0]
+	grad := make([]float64, len(x))
+	for j, delta := range first.Delta {
+		for k, w := range first.Weights[j] {
+			grad[k] -= delta * w
+		}
+	}
+
+	return e.network.Transforms.Backward(input, grad)
+}
+
+// Saliency карта значимости как модуль градиента
+func (e *Explainer) Saliency(input []float64, class int) []float64 {
+	grad := e.Gradient(input, class)
+	for i := range grad {
+		grad[i] = math.Abs(grad[i])
+	}
+	return grad
+}
+
+// SmoothGrad усредняет модуль градиента по Samples копиям входа с гауссовым шумом
+func (e *Explainer) SmoothGrad(input []float64, class int) []float64 {
+	result := make([]float64, len(input))
+	noisy := make([]float64, len(input))
+
+	for s := 0; s < e.Samples; s++ {
+		for i, value := range input {
+			noisy[i] = value + e.rng.NormFloat64()*e.Noise
+		}
+		for i, g := range e.Gradient(noisy, class) {
+			result[i] += math.Abs(g) / float64(e.Samples)
+		}
+	}
+	return result
+}
+
+// IntegratedGradients интегрирует градиент вдоль пути от черного изображения до входа
+// (правило средних точек). Знак результата показывает, за или против класса говорит пиксель
+func (e *Explainer) IntegratedGradients(input []float64, class int) []float64 {
+	result := make([]float64, len(input))
+	scaled := make([]float64, len(input))
+
+	for s := 0; s < e.Steps; s++ {
+		alpha := (float64(s) + 0.5) / float64(e.Steps)
+		for i, value := range input {
+			scaled[i] = value * alpha
+		}
+		for i, g := range e.Gradient(scaled, class) {
+			result[i] += g / float64(e.Steps)
+		}
+	}
+
+	for i, value := range input {
+		result[i] *= value
+	}
+	return result
+}
+
+// NormalizeAttribution делит карту значимости на максимум модуля, приводя ее к -1..1
+func NormalizeAttribution(attribution []float64) []float64 {
+	var scale float64
+	for _, a := range attribution {
+		scale = max(scale, math.Abs(a))
+	}
+
+	result := make([]float64, len(attribution))
+	if scale == 0 {
+		return result
+	}
+	for i, a := range attribution {
+		result[i] = a / scale
+	}
+	return result
+}
+
+// overlayColor цвет клетки с яркостью ink (0..1, как на холсте) под картой значимости -1..1
+func overlayColor(ink, value float64) color.RGBA {
+	gray := 255 * (1 - ink)
+	tint := divergingColor(value)
+	alpha := min(math.Abs(value), 1)
+	return color.RGBA{
+		R: uint8(gray*(1-alpha) + float64(tint.R)*alpha),
+		G: uint8(gray*(1-alpha) + float64(tint.G)*alpha),
+		B: uint8(gray*(1-alpha) + float64(tint.B)*alpha),
+		A: 255,
+	}
+}
+
+// ExplanationImage рисует изображение 28x28 с наложенной картой значимости,
+// каждый пиксель увеличивается до квадрата cell x cell
+func ExplanationImage(input, attribution []float64, cell int) image.Image {
+	attribution = NormalizeAttribution(attribution)
+
+	img := image.NewRGBA(image.Rect(0, 0, ImageSide*cell, ImageSide*cell))
+	for y := 0; y < ImageSide*cell; y++ {
+		for x := 0; x < ImageSide*cell; x++ {
+			idx := (y/cell)*ImageSide + x/cell
+			img.SetRGBA(x, y, overlayColor(input[idx], attribution[idx]))
+		}
+	}
+	return img
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	noExplanation    = "Нет"
+	predictedClass   = "предсказанная"
+	explanationScale = 10 // Увеличение пикселя при экспорте в PNG
+)
+
+// explanation последняя вычисленная карта значимости для экспорта
+type explanation struct {
+	input       []float64 // Вход сети 28x28
+	attribution []float64 // Значимость пикселей входа
+	method      string
+	class       int
+}
+
+// buildExplainPanel создает панель выбора метода объяснения и класса
+func (p *Predictor) buildExplainPanel() fyne.CanvasObject {
+	methods := []string{noExplanation}
+	for _, method := range []string{ExplainGradient, ExplainSmoothGrad, ExplainIntegrated} {
+		methods = append(methods, ExplainMethods[method])
+	}
+	p.explainSelect = widget.NewSelect(methods, nil)
+	p.explainSelect.SetSelected(noExplanation)
+	p.explainSelect.OnChanged = func(string) { p.predict() }
+
+	classes := []string{predictedClass}
+	for i := 0; i < 10; i++ {
+		classes = append(classes, strconv.Itoa(i))
+	}
+	p.explainClass = widget.NewSelect(classes, nil)
+	p.explainClass.SetSelected(predictedClass)
+	p.explainClass.OnChanged = func(string) { p.predict() }
+
+	exportBtn := widget.NewButton("Сохранить объяснение...", p.exportExplanation)
+
+	return container.NewVBox(
+		widget.NewSeparator(),
+		widget.NewLabel("Объяснение"),
+		p.explainSelect,
+		widget.NewLabel("Для цифры"),
+		p.explainClass,
+		exportBtn,
+	)
+}
+
+// explain накладывает на холст карту значимости выбранным методом
+func (p *Predictor) explain(network *Network, input []float64, prediction int) {
+	method := ""
+	for key, name := range ExplainMethods {
+		if name == p.explainSelect.Selected {
+			method = key
+		}
+	}
+	if method == "" {
+		p.lastExplanation = nil
+		p.grid.SetOverlay(nil)
+		return
+	}
+
+	class := prediction
+	if p.explainClass.SelectedIndex() > 0 {
+		class = p.explainClass.SelectedIndex() - 1
+	}
+
+	attribution, err := NewExplainer(network).Explain(method, input, class)
+	if err != nil {
+		dialog.ShowError(err, p.window)
+		return
+	}
+	p.lastExplanation = &explanation{input: input, attribution: attribution, method: method, class: class}
+
+	// Карта построена для нормализованного входа, на холсте ее нужно вернуть к исходным координатам
+	overlay := attribution
+	if p.normalizeCheck.Checked && !p.grid.MNISTBase() {
+		overlay = MapFromMNIST(attribution, p.grid.getDataForPredict(), GridSize, GridSize)
+	}
+	p.grid.SetOverlay(NormalizeAttribution(overlay))
+}
+
+// exportExplanation сохраняет вход сети с наложенной картой значимости в PNG
+func (p *Predictor) exportExplanation() {
+	e := p.lastExplanation
+	if e == nil {
+		dialog.ShowInformation("Нет объяснения", "Выберите метод объяснения и нарисуйте цифру", p.window)
+		return
+	}
+
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, p.window)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		writer.Close()
+
+		if err := SavePNG(ExplanationImage(e.input, e.attribution, explanationScale), writer.URI().Path()); err != nil {
+			dialog.ShowError(err, p.window)
+		}
+	}, p.window)
+	save.SetFileName(fmt.Sprintf("explanation_%s_%d.png", e.method, e.class))
+	save.SetFilter(storage.NewExtensionFileFilter([]string{".png"}))
+	save.Show()
+}
@@ -0,0 +1,298 @@
+package main
+
+//go:generate protoc -I proto --go_out=. --go_opt=module=github.com/igntnk/mnist --go-grpc_out=. --go-grpc_opt=module=github.com/igntnk/mnist proto/mnist.proto
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"runtime/debug"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/igntnk/mnist/mnistpb"
+)
+
+const (
+	maxGRPCBatch  = 10000 // Наибольшее число изображений в одном вызове PredictBatch
+	maxLivePoints = 10000 // Наибольшее число точек всех штрихов в одном вызове PredictLive
+	minLiveSide   = 1     // Наименьшая сторона холста PredictLive в единицах координат
+)
+
+// grpcService реализация сервиса mnist.v1.Predictor поверх того же реестра моделей, что и HTTP-сервер
+type grpcService struct {
+	mnistpb.UnimplementedPredictorServer
+	registry *Registry
+	metrics  *Metrics
+}
+
+// NewGRPCServer создает gRPC-сервер предсказаний для моделей реестра, вызовы учитываются в metrics
+func NewGRPCServer(registry *Registry, metrics *Metrics) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.MaxRecvMsgSize(maxRequestSize),
+		grpc.ChainUnaryInterceptor(metrics.UnaryInterceptor, recoverUnary),
+		grpc.ChainStreamInterceptor(metrics.StreamInterceptor, recoverStream),
+	)
+	mnistpb.RegisterPredictorServer(server, &grpcService{registry: registry, metrics: metrics})
+	return server
+}
+
+// recoverUnary превращает панику обработчика в ошибку Internal. В отличие от net/http,
+// gRPC не перехватывает паники, и одна ошибка в обработчике остановила бы сервер
+func recoverUnary(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (response any, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = recoveredError(info.FullMethod, p)
+		}
+	}()
+	return handler(ctx, request)
+}
+
+// recoverStream превращает панику потокового обработчика в ошибку Internal
+func recoverStream(server any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = recoveredError(info.FullMethod, p)
+		}
+	}()
+	return handler(server, stream)
+}
+
+func recoveredError(method string, p any) error {
+	log.Printf("gRPC %s: паника: %v\n%s", method, p, debug.Stack())
+	return status.Error(codes.Internal, "внутренняя ошибка сервера")
+}
+
+// lookup находит модель реестра по имени из запроса
+func (s *grpcService) lookup(name string) (*registryEntry, error) {
+	entry, err := s.registry.lookup(name)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return entry, nil
+}
+
+// Predict предсказание для одного изображения
+func (s *grpcService) Predict(ctx context.Context, request *mnistpb.PredictRequest) (*mnistpb.PredictResponse, error) {
+	entry, err := s.lookup(request.GetModel())
+	if err != nil {
+		return nil, err
+	}
+
+	input, err := grpcInput(request)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	output, source, err := entry.predict(ctx, input)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	s.metrics.observePrediction(entry.name, output)
+	return grpcResponse(output, source), nil
+}
+
+// PredictBatch читает изображения до конца потока и выполняет их одним батчем
+// на модели, указанной в первом сообщении
+func (s *grpcService) PredictBatch(stream grpc.ClientStreamingServer[mnistpb.PredictRequest, mnistpb.PredictBatchResponse]) error {
+	var inputs [][]float64
+	model := ""
+	for {
+		request, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if len(inputs) == maxGRPCBatch {
+			return status.Errorf(codes.ResourceExhausted, "в батче больше %d изображений", maxGRPCBatch)
+		}
+
+		input, err := grpcInput(request)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "изображение %d: %v", len(inputs), err)
+		}
+		if len(inputs) == 0 {
+			model = request.GetModel()
+		}
+		inputs = append(inputs, input)
+	}
+	if len(inputs) == 0 {
+		return status.Error(codes.InvalidArgument, "в потоке нет изображений")
+	}
+
+	entry, err := s.lookup(model)
+	if err != nil {
+		return err
+	}
+
+	network, source := entry.model.Network(), entry.model.Source()
+	if network == nil {
+		return grpcError(errNoModel)
+	}
+
+	outputs, err := predictBatch(network, inputs)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	response := &mnistpb.PredictBatchResponse{Predictions: make([]*mnistpb.PredictResponse, len(inputs))}
+	for i, output := range outputs {
+		s.metrics.observePrediction(entry.name, output)
+		response.Predictions[i] = grpcResponse(output, source)
+	}
+	return stream.SendAndClose(response)
+}
+
+// PredictLive рисует штрихи на холсте quickDrawRender так же, как при загрузке QuickDraw,
+// и отправляет предсказание через каждые Every точек или после каждого штриха
+func (s *grpcService) PredictLive(request *mnistpb.LiveRequest, stream grpc.ServerStreamingServer[mnistpb.LivePrediction]) error {
+	entry, err := s.lookup(request.GetModel())
+	if err != nil {
+		return err
+	}
+
+	strokes, err := liveStrokes(request)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// В метриках учитывается только предсказание для законченного рисунка,
+	// промежуточные сместили бы распределение классов и уверенности
+	canvas := newRenderCanvas()
+	var final []float64
+	send := func(stroke, point int) error {
+		output, source, err := entry.predict(stream.Context(), renderedImage(canvas))
+		if err != nil {
+			return grpcError(err)
+		}
+		final = output
+		return stream.Send(&mnistpb.LivePrediction{
+			Stroke:     int32(stroke),
+			Point:      int32(point),
+			Prediction: grpcResponse(output, source),
+		})
+	}
+
+	every := int(request.GetEvery())
+	drawn, sent := 0, false
+	for i, stroke := range strokes {
+		for j := range stroke.Points {
+			if err := stream.Context().Err(); err != nil {
+				return status.FromContextError(err).Err()
+			}
+			canvas.drawStroke(stroke, j)
+			drawn++
+
+			last := j == len(stroke.Points)-1
+			if (every > 0 && drawn%every == 0) || (every == 0 && last) ||
+				(every > 0 && last && i == len(strokes)-1) {
+				if err := send(i, j); err != nil {
+					return err
+				}
+				sent = true
+			}
+		}
+	}
+
+	if !sent {
+		// Рисунок без точек: одно предсказание для пустого холста
+		if err := send(-1, -1); err != nil {
+			return err
+		}
+	}
+	s.metrics.observePrediction(entry.name, final)
+	return nil
+}
+
+// liveStrokes переводит штрихи запроса в мазки холста quickDrawRender.
+// Точки далеко за пределами холста прижимаются к полосе шириной в сторону холста вокруг него:
+// нарисованное там все равно не попадет на холст, а отрисовка не растянется на огромные отрезки
+func liveStrokes(request *mnistpb.LiveRequest) ([]*Stroke, error) {
+	side := float64(request.GetSide())
+	if side == 0 {
+		side = quickDrawRender
+	}
+	if math.IsNaN(side) || math.IsInf(side, 0) || side < minLiveSide {
+		return nil, fmt.Errorf("неверная сторона холста %g, ожидается не меньше %d", side, minLiveSide)
+	}
+	scale := quickDrawRender / side
+
+	points := 0
+	strokes := make([]*Stroke, len(request.GetStrokes()))
+	for i, stroke := range request.GetStrokes() {
+		xs, ys, ts := stroke.GetX(), stroke.GetY(), stroke.GetT()
+		if len(xs) != len(ys) || (len(ts) > 0 && len(ts) != len(xs)) {
+			return nil, fmt.Errorf("штрих %d: массивы координат разной длины", i)
+		}
+		if points += len(xs); points > maxLivePoints {
+			return nil, fmt.Errorf("в рисунке больше %d точек", maxLivePoints)
+		}
+
+		strokes[i] = &Stroke{Radius: quickDrawRadius, Intensity: 1}
+		for j := range xs {
+			var t int64
+			if len(ts) > 0 {
+				t = ts[j]
+			}
+			x, y := float64(xs[j]), float64(ys[j])
+			if math.IsNaN(x) || math.IsInf(x, 0) || math.IsNaN(y) || math.IsInf(y, 0) {
+				return nil, fmt.Errorf("штрих %d, точка %d: неверные координаты (%g, %g)", i, j, x, y)
+			}
+			x = min(max(x, -side), 2*side)
+			y = min(max(y, -side), 2*side)
+			strokes[i].addPoint(x*scale, y*scale, t)
+		}
+	}
+	return strokes, nil
+}
+
+// grpcInput извлекает изображение 28x28 из запроса
+func grpcInput(request *mnistpb.PredictRequest) ([]float64, error) {
+	switch input := request.GetInput().(type) {
+	case *mnistpb.PredictRequest_Pixels:
+		values := input.Pixels.GetValues()
+		if len(values) != ImagePixels {
+			return nil, fmt.Errorf("ожидается %d пикселей, получено %d", ImagePixels, len(values))
+		}
+		pixels := make([]float64, len(values))
+		for i, v := range values {
+			pixels[i] = float64(v)
+		}
+		return pixels, nil
+
+	case *mnistpb.PredictRequest_Image:
+		pixels, err := DecodeImage(bytes.NewReader(input.Image))
+		if err != nil {
+			return nil, fmt.Errorf("не удалось прочитать изображение: %w", err)
+		}
+		return pixels, nil
+	}
+
+	return nil, errors.New("не задано ни pixels, ни image")
+}
+
+// grpcResponse собирает ответ из вероятностей классов
+func grpcResponse(output []float64, source string) *mnistpb.PredictResponse {
+	prediction := ArgMax(output)
+	return &mnistpb.PredictResponse{
+		Prediction:    int32(prediction),
+		Confidence:    output[prediction],
+		Probabilities: output,
+		Model:         source,
+	}
+}
+
+// grpcError переводит ошибку предсказания в статус gRPC
+func grpcError(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(codes.Unavailable, err.Error())
+}
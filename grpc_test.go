@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/igntnk/mnist/mnistpb"
+)
+
+// startGRPC запускает gRPC-сервер на bufconn с одной случайной моделью
+// и возвращает клиента и саму сеть для сравнения результатов
+func startGRPC(t *testing.T) (mnistpb.PredictorClient, *Network) {
+	t.Helper()
+
+	network := NewNetwork([]int{ImagePixels, 16, 10})
+	path := filepath.Join(t.TempDir(), "test.json")
+	if err := network.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	registry, err := OpenRegistry(path, "", 8, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(registry.Close)
+
+	listener := bufconn.Listen(1 << 20)
+	server := NewGRPCServer(registry, NewMetrics(registry, 0.5))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return mnistpb.NewPredictorClient(conn), network
+}
+
+// testDigit изображение 28x28 с вертикальной чертой
+func testDigit() []float64 {
+	pixels := make([]float64, ImagePixels)
+	for y := 6; y < 22; y++ {
+		pixels[y*ImageSide+13] = 1
+		pixels[y*ImageSide+14] = 1
+	}
+	return pixels
+}
+
+func pixelsRequest(pixels []float64) *mnistpb.PredictRequest {
+	values := make([]float32, len(pixels))
+	for i, v := range pixels {
+		values[i] = float32(v)
+	}
+	return &mnistpb.PredictRequest{Input: &mnistpb.PredictRequest_Pixels{Pixels: &mnistpb.Pixels{Values: values}}}
+}
+
+// checkResponse сравнивает ответ сервера с предсказанием сети для input
+func checkResponse(t *testing.T, network *Network, input []float64, response *mnistpb.PredictResponse) {
+	t.Helper()
+
+	want := network.Predict(input)
+	if int(response.GetPrediction()) != ArgMax(want) {
+		t.Errorf("prediction = %d, ожидается %d", response.GetPrediction(), ArgMax(want))
+	}
+	if len(response.GetProbabilities()) != len(want) {
+		t.Fatalf("получено %d вероятностей, ожидается %d", len(response.GetProbabilities()), len(want))
+	}
+	for i, p := range response.GetProbabilities() {
+		if math.Abs(p-want[i]) > 1e-9 {
+			t.Errorf("probabilities[%d] = %g, ожидается %g", i, p, want[i])
+		}
+	}
+}
+
+func TestGRPCPredictPixels(t *testing.T) {
+	client, network := startGRPC(t)
+
+	input := testDigit()
+	response, err := client.Predict(context.Background(), pixelsRequest(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResponse(t, network, input, response)
+
+	_, err = client.Predict(context.Background(), pixelsRequest(input[:100]))
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("для 100 пикселей получено %v, ожидается InvalidArgument", err)
+	}
+}
+
+func TestGRPCPredictPNG(t *testing.T) {
+	client, network := startGRPC(t)
+
+	// Темная черта на белом фоне, как рисунок пользователя
+	img := image.NewGray(image.Rect(0, 0, 56, 56))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	for y := 10; y < 46; y++ {
+		for x := 25; x < 31; x++ {
+			img.SetGray(x, y, color.Gray{})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	input, err := DecodeImage(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := client.Predict(context.Background(),
+		&mnistpb.PredictRequest{Input: &mnistpb.PredictRequest_Image{Image: buf.Bytes()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResponse(t, network, input, response)
+}
+
+func TestGRPCPredictBatch(t *testing.T) {
+	client, network := startGRPC(t)
+
+	stream, err := client.PredictBatch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs := make([][]float64, 5)
+	for i := range inputs {
+		inputs[i] = make([]float64, ImagePixels)
+		for j := range inputs[i] {
+			inputs[i][j] = float64((i*31+j*7)%9) / 8 // Точно представимы во float32
+		}
+		if err := stream.Send(pixelsRequest(inputs[i])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	response, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(response.GetPredictions()) != len(inputs) {
+		t.Fatalf("получено %d предсказаний, ожидается %d", len(response.GetPredictions()), len(inputs))
+	}
+	for i, prediction := range response.GetPredictions() {
+		checkResponse(t, network, inputs[i], prediction)
+	}
+}
+
+func TestGRPCPredictBatchEmpty(t *testing.T) {
+	client, _ := startGRPC(t)
+
+	stream, err := client.PredictBatch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = stream.CloseAndRecv()
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("для пустого потока получено %v, ожидается InvalidArgument", err)
+	}
+}
+
+func TestGRPCPredictLive(t *testing.T) {
+	client, network := startGRPC(t)
+
+	// Два штриха из 5 и 3 точек на холсте 100x100
+	strokes := []*mnistpb.Stroke{
+		{X: []float32{50, 50, 50, 50, 50}, Y: []float32{10, 30, 50, 70, 90}},
+		{X: []float32{30, 50, 70}, Y: []float32{30, 10, 30}},
+	}
+	request := &mnistpb.LiveRequest{Strokes: strokes, Side: 100}
+
+	// Финальное предсказание — для рисунка из всех штрихов
+	drawn, err := liveStrokes(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	canvas := newRenderCanvas()
+	for _, stroke := range drawn {
+		for j := range stroke.Points {
+			canvas.drawStroke(stroke, j)
+		}
+	}
+	final := renderedImage(canvas)
+
+	tests := []struct {
+		every    uint32
+		messages int
+	}{
+		{every: 0, messages: 2}, // После каждого штриха
+		{every: 1, messages: 8}, // После каждой точки
+		{every: 3, messages: 3}, // После 3-й и 6-й точки и в конце рисунка
+		{every: 4, messages: 2}, // После 4-й и 8-й (последней) точки
+	}
+	for _, tt := range tests {
+		request.Every = tt.every
+		stream, err := client.PredictLive(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var messages []*mnistpb.LivePrediction
+		for {
+			message, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			messages = append(messages, message)
+		}
+
+		if len(messages) != tt.messages {
+			t.Errorf("every=%d: получено %d сообщений, ожидается %d", tt.every, len(messages), tt.messages)
+			continue
+		}
+		last := messages[len(messages)-1]
+		if last.GetStroke() != 1 || last.GetPoint() != 2 {
+			t.Errorf("every=%d: последнее сообщение для штриха %d точки %d, ожидается 1 и 2",
+				tt.every, last.GetStroke(), last.GetPoint())
+		}
+		checkResponse(t, network, final, last.GetPrediction())
+	}
+}
+
+func TestGRPCPredictLiveInvalid(t *testing.T) {
+	client, _ := startGRPC(t)
+
+	requests := map[string]*mnistpb.LiveRequest{
+		"сторона":    {Side: 0.01, Strokes: []*mnistpb.Stroke{{X: []float32{1}, Y: []float32{1}}}},
+		"координаты": {Strokes: []*mnistpb.Stroke{{X: []float32{float32(math.NaN())}, Y: []float32{1}}}},
+		"длина":      {Strokes: []*mnistpb.Stroke{{X: []float32{1, 2}, Y: []float32{1}}}},
+		"точки":      {Strokes: []*mnistpb.Stroke{{X: make([]float32, maxLivePoints+1), Y: make([]float32, maxLivePoints+1)}}},
+	}
+	for name, request := range requests {
+		stream, err := client.PredictLive(context.Background(), request)
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s: получено %v, ожидается InvalidArgument", name, err)
+		}
+	}
+}
@@ -0,0 +1,478 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Predictor окно "Number Predictor": рисование цифры и ее распознавание
+type Predictor struct {
+	app    fyne.App
+	window fyne.Window
+	model  *Model
+
+	grid           *DrawGrid // Рисунок 28x28, который получает сеть
+	hires          *DrawGrid // Холст высокого разрешения
+	hiresSmall     []float64 // Рисунок холста высокого разрешения, уменьшенный до 28x28
+	hiresCheck     *widget.Check
+	chart          *ProbabilityChart
+	label          *widget.Label
+	status         *widget.Label
+	preview        *canvas.Image
+	normalizeCheck *widget.Check
+	liveCheck      *widget.Check
+	debounce       *time.Timer
+	weights        *weightsTab
+
+	explainSelect   *widget.Select
+	explainClass    *widget.Select
+	lastExplanation *explanation
+
+	mnistMu    sync.Mutex
+	mnistTrain *Dataset
+	mnistTest  *Dataset
+}
+
+// RunGUI открывает окно предсказаний для модели и блокируется до его закрытия
+func RunGUI(model *Model) {
+	a := app.New()
+	p := NewPredictor(a, model)
+	p.window.ShowAndRun()
+}
+
+// NewPredictor создает окно предсказаний
+func NewPredictor(a fyne.App, model *Model) *Predictor {
+	p := &Predictor{
+		app:    a,
+		window: a.NewWindow("Number Predictor"),
+		model:  model,
+	}
+
+	p.window.SetMainMenu(p.buildMenu())
+	tabs := container.NewAppTabs(
+		container.NewTabItem("Рисование", p.buildDrawTab()),
+		container.NewTabItem("Число", p.buildNumberTab()),
+		container.NewTabItem("Обучение", p.buildTrainingTab()),
+		container.NewTabItem("Тестовая выборка", p.buildTestBrowserTab()),
+		container.NewTabItem("Веса и активации", p.buildWeightsTab()),
+	)
+	p.window.SetContent(container.NewBorder(nil, p.buildStatus(), nil, nil, tabs))
+	p.window.Resize(fyne.NewSize(GridSize*PixelSize+10, GridSize*PixelSize+10))
+
+	p.window.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyZ, Modifier: fyne.KeyModifierShortcutDefault},
+		func(fyne.Shortcut) { p.activeCanvas().Undo() })
+	p.window.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyY, Modifier: fyne.KeyModifierShortcutDefault},
+		func(fyne.Shortcut) { p.activeCanvas().Redo() })
+
+	// Перетаскивание в окно: изображение распознается, файл .json загружается как модель
+	p.window.SetOnDropped(func(_ fyne.Position, uris []fyne.URI) {
+		if len(uris) == 0 {
+			return
+		}
+
+		load := p.loadImage
+		if strings.EqualFold(uris[0].Extension(), ".json") {
+			load = p.loadModel
+		}
+		if err := load(uris[0].Path()); err != nil {
+			dialog.ShowError(err, p.window)
+		}
+	})
+
+	p.measureAccuracy(model.Network())
+	return p
+}
+
+// buildMenu создает главное меню окна
+func (p *Predictor) buildMenu() *fyne.MainMenu {
+	return fyne.NewMainMenu(
+		fyne.NewMenu("Модель",
+			fyne.NewMenuItem("Открыть модель...", p.openModelDialog),
+		),
+		fyne.NewMenu("Изображение",
+			fyne.NewMenuItem("Открыть изображение...", p.openImageDialog),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("Открыть штрихи QuickDraw...", p.openStrokesDialog),
+			fyne.NewMenuItem("Сохранить штрихи QuickDraw...", p.saveStrokesDialog),
+		),
+	)
+}
+
+// buildStatus создает строку состояния с описанием модели
+func (p *Predictor) buildStatus() fyne.CanvasObject {
+	p.status = widget.NewLabel(p.model.Describe())
+	return p.status
+}
+
+// buildDrawTab создает холст для рисования и панель предсказания
+func (p *Predictor) buildDrawTab() fyne.CanvasObject {
+	grid := NewDrawGrid()
+	p.grid = grid
+
+	canvasArea := p.buildCanvasArea()
+
+	clearBtn := widget.NewButton("Очистить", func() {
+		p.activeCanvas().Clear()
+	})
+	undoBtn := widget.NewButton("Отменить", func() { p.activeCanvas().Undo() })
+	redoBtn := widget.NewButton("Повторить", func() { p.activeCanvas().Redo() })
+	replayBtn := widget.NewButton("Воспроизвести", func() {
+		p.activeCanvas().Replay(15 * time.Millisecond)
+	})
+	eraserCheck := widget.NewCheck("Ластик", func(checked bool) {
+		grid.EraseMode = checked
+		p.hires.EraseMode = checked
+	})
+
+	brushSize := widget.NewSlider(0.5, 3)
+	brushSize.Step = 0.1
+	brushSize.SetValue(grid.BrushRadius)
+	brushSize.OnChanged = func(value float64) {
+		grid.BrushRadius = value
+		p.hires.BrushRadius = value * hiResScale
+	}
+
+	brushIntensity := widget.NewSlider(0.1, 1)
+	brushIntensity.Step = 0.05
+	brushIntensity.SetValue(grid.BrushIntensity)
+	brushIntensity.OnChanged = func(value float64) {
+		grid.BrushIntensity = value
+		p.hires.BrushIntensity = value
+	}
+
+	p.label = widget.NewLabel("Тут будет отображаться предсказание сети")
+
+	// Предпросмотр входа сети после нормализации
+	p.preview = canvas.NewImageFromImage(ImageFromPixels(make([]float64, ImagePixels)))
+	p.preview.ScaleMode = canvas.ImageScalePixels
+	p.preview.FillMode = canvas.ImageFillContain
+	p.preview.SetMinSize(fyne.NewSize(ImageSide*4, ImageSide*4))
+
+	p.normalizeCheck = widget.NewCheck("Нормализация как в MNIST", nil)
+	p.normalizeCheck.SetChecked(true)
+
+	p.chart = NewProbabilityChart()
+
+	loadToNetworkBtn := widget.NewButton("Получить предсказание", p.predict)
+
+	// Живое предсказание пересчитывается после паузы в рисовании
+	p.liveCheck = widget.NewCheck("Предсказывать во время рисования", nil)
+	p.liveCheck.SetChecked(true)
+	grid.OnChanged = p.schedulePredict
+
+	return container.NewHBox(container.NewVBox(
+		canvasArea,
+		container.NewHBox(clearBtn, undoBtn, redoBtn, replayBtn, eraserCheck),
+		p.hiresCheck,
+		widget.NewForm(
+			widget.NewFormItem("Размер кисти", brushSize),
+			widget.NewFormItem("Нажим", brushIntensity),
+		),
+	),
+		container.NewVBox(
+			loadToNetworkBtn,
+			p.liveCheck,
+			p.normalizeCheck,
+			p.preview,
+			p.buildExplainPanel(),
+		),
+		container.NewVBox(
+			p.label,
+			p.chart,
+			p.buildCorrectionPanel(),
+		),
+	)
+}
+
+// schedulePredict откладывает живое предсказание до паузы в рисовании
+func (p *Predictor) schedulePredict() {
+	if !p.liveCheck.Checked {
+		return
+	}
+	if p.debounce != nil {
+		p.debounce.Stop()
+	}
+	p.debounce = time.AfterFunc(150*time.Millisecond, func() {
+		fyne.Do(p.predict)
+	})
+}
+
+// predict распознает текущий рисунок
+func (p *Predictor) predict() {
+	network := p.model.Network()
+	if network == nil {
+		p.label.SetText("Сначала загрузите модель через меню \"Модель\"")
+		return
+	}
+
+	input := p.currentInput()
+	p.preview.Image = ImageFromPixels(input)
+	p.preview.Refresh()
+
+	output := network.Predict(input)
+	prediction := ArgMax(output)
+	confidence := output[prediction]
+
+	p.label.SetText(fmt.Sprintf("Нейронная сеть думает, что это цифра - %d \n Она уверрена в этом на %.2f%%", prediction, confidence*100))
+	p.chart.SetProbabilities(output)
+	p.weights.update(network, input)
+	p.explain(network, input, prediction)
+}
+
+// currentInput возвращает рисунок в том виде, в котором он подается сети.
+// Открытое изображение уже нормализовано при загрузке и не нормализуется повторно
+func (p *Predictor) currentInput() []float64 {
+	input := p.grid.getDataForPredict()
+	if p.normalizeCheck.Checked && !p.grid.MNISTBase() {
+		input = NormalizeToMNIST(input, GridSize, GridSize)
+	}
+	return input
+}
+
+// loadMNIST загружает обучающую и тестовую выборки MNIST один раз за сеанс.
+// Вызывается из фоновых горутин
+func (p *Predictor) loadMNIST() (*Dataset, *Dataset, error) {
+	p.mnistMu.Lock()
+	defer p.mnistMu.Unlock()
+
+	if p.mnistTrain == nil {
+		trainImages, trainLabels, testImages, testLabels, err := LoadMNISTFromBin()
+		if err != nil {
+			return nil, nil, err
+		}
+		p.mnistTrain = &Dataset{Images: trainImages, Labels: trainLabels}
+		p.mnistTest = &Dataset{Images: testImages, Labels: testLabels}
+	}
+
+	return p.mnistTrain, p.mnistTest, nil
+}
+
+// openModelDialog выбирает файл модели и заменяет текущую сеть
+func (p *Predictor) openModelDialog() {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, p.window)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		reader.Close()
+
+		if err := p.loadModel(reader.URI().Path()); err != nil {
+			dialog.ShowError(err, p.window)
+		}
+	}, p.window)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+	open.Show()
+}
+
+// openImageDialog выбирает PNG/JPEG и распознает его
+func (p *Predictor) openImageDialog() {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, p.window)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		reader.Close()
+
+		if err := p.loadImage(reader.URI().Path()); err != nil {
+			dialog.ShowError(err, p.window)
+		}
+	}, p.window)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".png", ".jpg", ".jpeg", ".gif"}))
+	open.Show()
+}
+
+// loadImage приводит изображение к формату MNIST, показывает его на холсте и распознает
+func (p *Predictor) loadImage(filename string) error {
+	image, err := LoadImageFile(filename)
+	if err != nil {
+		return err
+	}
+
+	p.hiresCheck.SetChecked(false)
+	p.grid.SetMNISTImage(image)
+	p.predict()
+	return nil
+}
+
+// openStrokesDialog выбирает файл NDJSON и воспроизводит первый рисунок из него
+func (p *Predictor) openStrokesDialog() {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, p.window)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		reader.Close()
+
+		if err := p.loadStrokes(reader.URI().Path()); err != nil {
+			dialog.ShowError(err, p.window)
+		}
+	}, p.window)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".ndjson"}))
+	open.Show()
+}
+
+// loadStrokes переносит первый рисунок файла NDJSON на холст и воспроизводит его
+func (p *Predictor) loadStrokes(filename string) error {
+	drawings, err := LoadQuickDraw(filename)
+	if err != nil {
+		return err
+	}
+	if len(drawings) == 0 {
+		return fmt.Errorf("%s: нет рисунков", filename)
+	}
+
+	target := p.activeCanvas()
+	strokes, err := drawings[0].Strokes(float64(target.Cols), target.BrushRadius, target.BrushIntensity)
+	if err != nil {
+		return err
+	}
+
+	target.SetStrokes(strokes)
+	target.Replay(15 * time.Millisecond)
+	return nil
+}
+
+// saveStrokesDialog сохраняет мазки текущего рисунка в файл NDJSON. Подпись выбирает
+// пользователь, по умолчанию предлагается предсказанная цифра; рисунок отмечается
+// распознанным, только если подпись совпадает с предсказанием
+func (p *Predictor) saveStrokesDialog() {
+	strokes := p.activeCanvas().Strokes()
+	network := p.model.Network()
+	if len(strokes) == 0 || network == nil {
+		dialog.ShowInformation("Нет рисунка", "Нарисуйте цифру и загрузите модель", p.window)
+		return
+	}
+	prediction := ArgMax(network.Predict(p.currentInput()))
+
+	labels := make([]string, 10)
+	for i := range labels {
+		labels[i] = strconv.Itoa(i)
+	}
+	labelSelect := widget.NewSelect(labels, nil)
+	labelSelect.SetSelectedIndex(prediction)
+	timeCheck := widget.NewCheck("Записывать время точек", nil)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Цифра", labelSelect),
+		widget.NewFormItem("", timeCheck),
+	}
+	items[1].HintText = "Третий массив штриха, как в исходной схеме QuickDraw"
+
+	dialog.ShowForm("Сохранение штрихов", "Сохранить", "Отмена", items, func(ok bool) {
+		label := labelSelect.SelectedIndex()
+		if !ok || label < 0 {
+			return
+		}
+		drawing := NewQuickDrawing(strokes, labels[label], label == prediction, timeCheck.Checked)
+
+		save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, p.window)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			writer.Close()
+
+			if err := AppendQuickDraw(writer.URI().Path(), drawing); err != nil {
+				dialog.ShowError(err, p.window)
+			}
+		}, p.window)
+		save.SetFileName("drawings.ndjson")
+		save.SetFilter(storage.NewExtensionFileFilter([]string{".ndjson"}))
+		save.Show()
+	}, p.window)
+}
+
+// loadModel загружает модель из файла и обновляет окно
+func (p *Predictor) loadModel(filename string) error {
+	network, err := LoadNetwork(filename)
+	if err != nil {
+		return err
+	}
+
+	p.model.Set(network, filename)
+	p.status.SetText(p.model.Describe())
+	p.measureAccuracy(network)
+	p.schedulePredict()
+	return nil
+}
+
+// publishNetwork делает сеть текущей для предсказаний. Безопасен для вызова из любой горутины
+func (p *Predictor) publishNetwork(network *Network, source string) {
+	p.model.Set(network, source)
+	fyne.Do(func() {
+		p.status.SetText(p.model.Describe())
+		p.schedulePredict()
+	})
+}
+
+// measureAccuracy в фоне вычисляет точность на тестовой выборке MNIST,
+// если она не была сохранена вместе с моделью. Опубликованная сеть не изменяется:
+// точность записывается в копию, которая заменяет сеть, если та еще текущая
+func (p *Predictor) measureAccuracy(network *Network) {
+	if network == nil || network.TestAccuracy > 0 {
+		return
+	}
+
+	go func() {
+		test, err := LoadBinDataset("data/test")
+		if err != nil {
+			return
+		}
+		accuracy := Evaluate(network, test.Images, test.Labels)
+
+		measured := network.Clone()
+		measured.TestAccuracy = accuracy
+
+		// Пока шел подсчет, могли загрузить другую модель
+		if p.model.Replace(network, measured) {
+			fyne.Do(func() {
+				p.status.SetText(p.model.Describe())
+			})
+		}
+	}()
+}
+
+// runGUI открывает окно предсказаний для сохраненной модели без обучения
+func runGUI(args []string) error {
+	flags := flag.NewFlagSet("gui", flag.ExitOnError)
+	modelPath := flags.String("model", "mnist_model.json", "файл модели")
+	flags.Parse(args)
+
+	model := NewModel(nil, "")
+	network, err := LoadNetwork(*modelPath)
+	if err != nil {
+		// Окно откроется без модели, ее можно выбрать через меню
+		log.Printf("Ошибка загрузки модели: %v", err)
+	} else {
+		model.Set(network, *modelPath)
+	}
+
+	RunGUI(model)
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"image"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	HiResSize     = 280 // Сторона холста высокого разрешения в пикселях рисунка
+	hiResScale    = HiResSize / GridSize
+	hiResCellSize = 2  // Холст показывается в масштабе 2:1
+	smallCellSize = 10 // Размер клетки уменьшенного рисунка 28x28 рядом с холстом
+)
+
+// buildCanvasArea создает область рисования: холст 28x28 или холст высокого разрешения,
+// рядом с которым показывается уменьшенный до 28x28 рисунок. Сеть всегда получает
+// вход из p.grid, поэтому в режиме высокого разрешения он только отображает результат
+func (p *Predictor) buildCanvasArea() fyne.CanvasObject {
+	p.hires = NewDrawGridSize(HiResSize, HiResSize, hiResCellSize)
+	p.hires.BrushRadius = p.grid.BrushRadius * hiResScale
+	p.hires.BrushIntensity = p.grid.BrushIntensity
+	p.hires.OnChanged = p.downsampleHiRes
+	p.hiresSmall = make([]float64, GridSize*GridSize)
+
+	area := container.NewHBox(p.grid)
+	p.hiresCheck = widget.NewCheck("Высокое разрешение", func(on bool) {
+		p.hires.Clear()
+		p.grid.ReadOnly = on
+		if on {
+			p.grid.CellSize = smallCellSize
+			area.Objects = []fyne.CanvasObject{p.hires, container.NewVBox(widget.NewLabel("Вход 28x28"), p.grid)}
+		} else {
+			p.grid.CellSize = PixelSize
+			area.Objects = []fyne.CanvasObject{p.grid}
+		}
+		area.Refresh()
+	})
+
+	return area
+}
+
+// activeCanvas возвращает холст, на котором сейчас рисует пользователь
+func (p *Predictor) activeCanvas() *DrawGrid {
+	if p.hiresCheck.Checked {
+		return p.hires
+	}
+	return p.grid
+}
+
+// downsampleHiRes уменьшает рисунок высокого разрешения до 28x28 усреднением по площади.
+// Пересчитываются только клетки, на которые попало последнее изменение холста
+func (p *Predictor) downsampleHiRes() {
+	changed := p.hires.LastChange
+	area := image.Rect(changed.Min.X/hiResScale, changed.Min.Y/hiResScale,
+		(changed.Max.X+hiResScale-1)/hiResScale, (changed.Max.Y+hiResScale-1)/hiResScale)
+	area = area.Intersect(image.Rect(0, 0, GridSize, GridSize))
+	if area.Empty() {
+		return
+	}
+
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			var sum float64
+			for hy := y * hiResScale; hy < (y+1)*hiResScale; hy++ {
+				for hx := x * hiResScale; hx < (x+1)*hiResScale; hx++ {
+					sum += p.hires.Data[hy][hx]
+				}
+			}
+			p.hiresSmall[y*GridSize+x] = sum / (hiResScale * hiResScale)
+		}
+	}
+	p.grid.SetImageRect(p.hiresSmall, area)
+}
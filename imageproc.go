@@ -0,0 +1,271 @@
+package main
+
+import (
+	"image"
+	"io"
+	"math"
+	"os"
+
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+const (
+	ImageSide   = 28                    // Сторона изображения MNIST
+	ImagePixels = ImageSide * ImageSide // Количество пикселей (входов сети)
+	DigitBox    = 20                    // Размер рамки, в которую вписывается цифра
+)
+
+// LoadImageFile загружает PNG/JPEG/GIF и приводит его к формату MNIST.
+// Изображения размером 28x28 считаются уже нормализованными
+func LoadImageFile(filename string) ([]float64, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return DecodeImage(file)
+}
+
+// DecodeImage читает PNG/JPEG/GIF из r и приводит его к формату MNIST, как LoadImageFile
+func DecodeImage(r io.Reader) ([]float64, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	pix, w, h := ImageToGray(img)
+	AutoInvert(pix, w, h)
+	if w == ImageSide && h == ImageSide {
+		return pix, nil
+	}
+	return NormalizeToMNIST(pix, w, h), nil
+}
+
+// ImageToGray переводит изображение в оттенки серого 0..1 (1 — белый).
+// Прозрачные пиксели считаются белым фоном
+func ImageToGray(img image.Image) ([]float64, int, int) {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	pix := make([]float64, w*h)
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, b, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			// Яркость по ITU-R 601 с наложением на белый фон
+			lum := (19595*r + 38470*g + 7471*b + 1<<15) >> 16
+			lum += 0xffff - a
+			pix[y*w+x] = math.Min(float64(lum)/0xffff, 1)
+		}
+	}
+
+	return pix, w, h
+}
+
+// AutoInvert приводит изображение к виду MNIST (светлая цифра на темном фоне).
+// Фон определяется по средней яркости рамки изображения
+func AutoInvert(pix []float64, w, h int) {
+	var sum float64
+	var count int
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x == 0 || y == 0 || x == w-1 || y == h-1 {
+				sum += pix[y*w+x]
+				count++
+			}
+		}
+	}
+
+	if count == 0 || sum/float64(count) <= 0.5 {
+		return
+	}
+
+	for i := range pix {
+		pix[i] = 1 - pix[i]
+	}
+}
+
+// NormalizeToMNIST вырезает цифру по ограничивающей рамке, вписывает ее
+// в квадрат 20x20 с сохранением пропорций и центрирует по центру масс в поле 28x28
+func NormalizeToMNIST(pix []float64, w, h int) []float64 {
+	result, _ := normalizeToMNIST(pix, w, h)
+	return result
+}
+
+// mnistPlacement положение исходного изображения в поле 28x28 после NormalizeToMNIST
+type mnistPlacement struct {
+	minX, minY     int     // Левый верхний угол рамки цифры в исходном изображении
+	scaleX, scaleY float64 // Масштаб по осям
+	offX, offY     int     // Сдвиг в поле 28x28 с учетом центрирования по центру масс
+}
+
+// toMNIST переводит координаты центра пикселя исходного изображения в координаты поля 28x28
+func (p mnistPlacement) toMNIST(x, y int) (float64, float64) {
+	return (float64(x-p.minX)+0.5)*p.scaleX + float64(p.offX) - 0.5,
+		(float64(y-p.minY)+0.5)*p.scaleY + float64(p.offY) - 0.5
+}
+
+// MapFromMNIST переносит значения, заданные в поле 28x28 нормализованного изображения
+// (например, карту значимости), обратно на исходное изображение w x h
+func MapFromMNIST(values, pix []float64, w, h int) []float64 {
+	result := make([]float64, w*h)
+	_, placement := normalizeToMNIST(pix, w, h)
+	if placement == nil {
+		return result
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			mx, my := placement.toMNIST(x, y)
+			result[y*w+x] = sampleBilinear(values, ImageSide, ImageSide, mx, my)
+		}
+	}
+	return result
+}
+
+// normalizeToMNIST выполняет NormalizeToMNIST и возвращает положение исходного изображения
+// в результате, nil для пустого изображения
+func normalizeToMNIST(pix []float64, w, h int) ([]float64, *mnistPlacement) {
+	result := make([]float64, ImagePixels)
+
+	minX, minY, maxX, maxY, ok := inkBounds(pix, w, h, 0.1)
+	if !ok {
+		return result, nil
+	}
+
+	cw := maxX - minX + 1
+	ch := maxY - minY + 1
+	crop := make([]float64, cw*ch)
+	for y := 0; y < ch; y++ {
+		copy(crop[y*cw:(y+1)*cw], pix[(minY+y)*w+minX:(minY+y)*w+maxX+1])
+	}
+
+	// Масштабируем с сохранением пропорций
+	scale := float64(DigitBox) / float64(max(cw, ch))
+	nw := max(1, int(math.Round(float64(cw)*scale)))
+	nh := max(1, int(math.Round(float64(ch)*scale)))
+	digit := ResizeArea(crop, cw, ch, nw, nh)
+
+	// Размещаем по центру поля, затем сдвигаем по центру масс
+	offX := (ImageSide - nw) / 2
+	offY := (ImageSide - nh) / 2
+	for y := 0; y < nh; y++ {
+		for x := 0; x < nw; x++ {
+			result[(offY+y)*ImageSide+offX+x] = digit[y*nw+x]
+		}
+	}
+
+	dx, dy := massShift(result, ImageSide, ImageSide)
+	placement := &mnistPlacement{
+		minX:   minX,
+		minY:   minY,
+		scaleX: float64(nw) / float64(cw),
+		scaleY: float64(nh) / float64(ch),
+		offX:   offX + dx,
+		offY:   offY + dy,
+	}
+	return shiftImage(result, ImageSide, ImageSide, dx, dy), placement
+}
+
+// ResizeArea масштабирует изображение усреднением по площади
+// (корректно работает как при уменьшении, так и при увеличении)
+func ResizeArea(src []float64, sw, sh, dw, dh int) []float64 {
+	dst := make([]float64, dw*dh)
+	sx := float64(sw) / float64(dw)
+	sy := float64(sh) / float64(dh)
+
+	for y := 0; y < dh; y++ {
+		y0 := float64(y) * sy
+		y1 := y0 + sy
+		for x := 0; x < dw; x++ {
+			x0 := float64(x) * sx
+			x1 := x0 + sx
+
+			var sum, area float64
+			for py := int(y0); py < sh && float64(py) < y1; py++ {
+				hy := math.Min(y1, float64(py+1)) - math.Max(y0, float64(py))
+				for px := int(x0); px < sw && float64(px) < x1; px++ {
+					wx := math.Min(x1, float64(px+1)) - math.Max(x0, float64(px))
+					sum += src[py*sw+px] * wx * hy
+					area += wx * hy
+				}
+			}
+
+			if area > 0 {
+				dst[y*dw+x] = sum / area
+			}
+		}
+	}
+
+	return dst
+}
+
+// CenterByMass сдвигает изображение так, чтобы центр масс оказался в центре поля
+func CenterByMass(pix []float64, w, h int) []float64 {
+	dx, dy := massShift(pix, w, h)
+	return shiftImage(pix, w, h, dx, dy)
+}
+
+// massShift вычисляет сдвиг, переносящий центр масс в центр поля
+func massShift(pix []float64, w, h int) (int, int) {
+	var mass, cx, cy float64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := pix[y*w+x]
+			mass += v
+			cx += v * float64(x)
+			cy += v * float64(y)
+		}
+	}
+
+	if mass == 0 {
+		return 0, 0
+	}
+
+	dx := int(math.Round(float64(w-1)/2 - cx/mass))
+	dy := int(math.Round(float64(h-1)/2 - cy/mass))
+
+	// Не даем цифре выйти за границы поля
+	minX, minY, maxX, maxY, _ := inkBounds(pix, w, h, 0)
+	dx = min(max(dx, -minX), w-1-maxX)
+	dy = min(max(dy, -minY), h-1-maxY)
+
+	return dx, dy
+}
+
+// shiftImage сдвигает изображение на (dx, dy), заполняя освободившееся место нулями
+func shiftImage(pix []float64, w, h, dx, dy int) []float64 {
+	result := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		ny := y + dy
+		if ny < 0 || ny >= h {
+			continue
+		}
+		for x := 0; x < w; x++ {
+			nx := x + dx
+			if nx < 0 || nx >= w {
+				continue
+			}
+			result[ny*w+nx] = pix[y*w+x]
+		}
+	}
+	return result
+}
+
+// inkBounds находит рамку пикселей со значением больше threshold
+func inkBounds(pix []float64, w, h int, threshold float64) (int, int, int, int, bool) {
+	minX, minY, maxX, maxY := w, h, -1, -1
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if pix[y*w+x] > threshold {
+				minX = min(minX, x)
+				minY = min(minY, y)
+				maxX = max(maxX, x)
+				maxY = max(maxY, y)
+			}
+		}
+	}
+	return minX, minY, maxX, maxY, maxX >= 0
+}
@@ -1,18 +1,37 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/app"
-	"fyne.io/fyne/v2/container"
-	"fyne.io/fyne/v2/driver/desktop"
-	"fyne.io/fyne/v2/widget"
 	"log"
 	"math/rand"
+	"os"
 	"time"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			if err := command.Run(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Использование: mnist [флаги] | mnist <подкоманда> [флаги]")
+		flag.PrintDefaults()
+		printCommands()
+	}
+	digitsDir := flag.String("digits", "", "каталог с собственными изображениями вида <метка>/*.png")
+	digitsTestSplit := flag.Float64("digits-test", 0.2, "доля собственных изображений для тестирования")
+	augment := flag.Bool("augment", false, "аугментировать обучающие изображения")
+	augmentProbs := flag.String("augment-p", "", "вероятности преобразований, например affine=0.5,elastic=0.3")
+	augmentSeed := flag.Int64("augment-seed", 1, "зерно генератора аугментации")
+	transforms := flag.String("transforms", "", "предобработка входа, например deskew,center,standardize")
+	flag.Parse()
+
 	rand.Seed(time.Now().UnixNano())
 
 	fmt.Println("=== Нейронная сеть для распознавания рукописных цифр MNIST ===")
@@ -27,91 +46,80 @@ func main() {
 	fmt.Printf("Загружено %d обучающих и %d тестовых изображений\n",
 		len(trainImages), len(testImages))
 
+	// Собственные изображения добавляются к обучающей выборке MNIST
+	var digitsTest *Dataset
+	if *digitsDir != "" {
+		digits, err := LoadImageFolder(*digitsDir)
+		if err != nil {
+			log.Fatal("Ошибка загрузки изображений:", err)
+		}
+
+		var digitsTrain *Dataset
+		digitsTrain, digitsTest = digits.Split(*digitsTestSplit)
+		trainImages = append(trainImages, digitsTrain.Images...)
+		trainLabels = append(trainLabels, digitsTrain.Labels...)
+
+		fmt.Printf("Загружено %d собственных изображений (%d для обучения, %d для тестирования)\n",
+			digits.Len(), digitsTrain.Len(), digitsTest.Len())
+	}
+
+	var augmenter *Augmenter
+	if *augment {
+		config := DefaultAugmentConfig()
+		if err := ParseAugmentProbabilities(*augmentProbs, &config); err != nil {
+			log.Fatal("Ошибка параметров аугментации:", err)
+		}
+		augmenter = NewAugmenter(config, *augmentSeed)
+	}
+
 	// 2. Создание нейронной сети
 	fmt.Println("\n2. Создание нейронной сети...")
-	network := NewNetwork([]int{784, 128, 64, 10}) // 784 входа, 2 скрытых слоя, 10 выходов
+	pipeline, err := ParsePipeline(*transforms)
+	if err != nil {
+		log.Fatal("Ошибка предобработки:", err)
+	}
+	pipeline.Fit(trainImages)
+
+	network := NewNetwork([]int{pipeline.InputSize(), 128, 64, 10}) // 784 входа (больше при pad), 2 скрытых слоя, 10 выходов
 	network.SetLearningRate(0.1)
+	network.Transforms = pipeline
+	if len(pipeline) > 0 {
+		fmt.Printf("Предобработка: %s\n", pipeline)
+	}
 
 	// 3. Обучение сети
 	fmt.Println("\n3. Начало обучения...")
 	epochs := 150
 	batchSize := 32
 
-	trainLosses := make([]float64, epochs)
-	trainAccuracies := make([]float64, epochs)
-
-	for epoch := 0; epoch < epochs; epoch++ {
-		startTime := time.Now()
-
-		// Перемешиваем данные
-		shuffledIndices := rand.Perm(len(trainImages))
-
-		var epochLoss float64
-		var correct int
-
-		// Обучение мини-батчами
-		for i := 0; i < len(shuffledIndices); i += batchSize {
-			end := i + batchSize
-			if end > len(shuffledIndices) {
-				end = len(shuffledIndices)
-			}
-
-			batchIndices := shuffledIndices[i:end]
-			batchLoss := 0.0
-			batchCorrect := 0
-
-			// Прямое распространение и обратное распространение для батча
-			for _, idx := range batchIndices {
-				image := trainImages[idx]
-				label := trainLabels[idx]
-
-				// Прямое распространение
-				output := network.Forward(image)
-
-				// Вычисляем потерю и точность
-				batchLoss += CrossEntropyLoss(output, label)
-				if ArgMax(output) == label {
-					batchCorrect++
-				}
-
-				// Обратное распространение ошибки
-				network.Backward(image, label)
-			}
-
-			// Обновление весов после батча
-			network.UpdateWeights(len(batchIndices))
-
-			epochLoss += batchLoss
-			correct += batchCorrect
-		}
-
-		// Статистика эпохи
-		avgLoss := epochLoss / float64(len(trainImages))
-		accuracy := float64(correct) / float64(len(trainImages))
-
-		trainLosses[epoch] = avgLoss
-		trainAccuracies[epoch] = accuracy
-
-		elapsed := time.Since(startTime)
-
+	trainer := NewTrainer(network, &Dataset{Images: trainImages, Labels: trainLabels}, epochs)
+	trainer.BatchSize = batchSize
+	trainer.Augmenter = augmenter
+	trainer.OnEpoch = func(stats EpochStats) {
 		fmt.Printf("Эпоха %d/%d | Loss: %.4f | Accuracy: %.2f%% | Время: %v\n",
-			epoch+1, epochs, avgLoss, accuracy*100, elapsed)
+			stats.Epoch+1, stats.Epochs, stats.Loss, stats.Accuracy*100, stats.Elapsed)
 
 		// Тестирование после каждой эпохи
-		if (epoch+1)%2 == 0 {
+		if (stats.Epoch+1)%2 == 0 {
 			testAccuracy := Evaluate(network, testImages, testLabels)
 			fmt.Printf("  Тестовая точность: %.2f%%\n", testAccuracy*100)
 		}
 	}
+	trainer.Run()
 
 	// 4. Финальное тестирование
 	fmt.Println("\n4. Финальное тестирование...")
 	testAccuracy := Evaluate(network, testImages, testLabels)
+	network.TestAccuracy = testAccuracy
 	fmt.Printf("Финальная точность на тестовой выборке: %.2f%%\n", testAccuracy*100)
+	if digitsTest != nil && digitsTest.Len() > 0 {
+		digitsAccuracy := Evaluate(network, digitsTest.Images, digitsTest.Labels)
+		fmt.Printf("Точность на собственных изображениях: %.2f%%\n", digitsAccuracy*100)
+	}
 
 	// 5. Визуализация результатов
 	fmt.Println("\n5. Создание графиков...")
-	if err := PlotTrainingResults(trainLosses, trainAccuracies); err != nil {
+	if err := PlotTrainingResults(trainer.Losses, trainer.Accuracies); err != nil {
 		fmt.Printf("Ошибка создания графиков: %v\n", err)
 	} else {
 		fmt.Println("Графики сохранены в training_results.png")
@@ -129,36 +137,5 @@ func main() {
 		fmt.Println("Модель сохранена в mnist_model.json")
 	}
 
-	a := app.New()
-	w := a.NewWindow("Number Predictor")
-
-	grid := NewDrawGrid()
-	clearBtn := widget.NewButton("Очистить", func() {
-		grid.Clear()
-	})
-
-	label := widget.NewLabel("Тут будет отображаться предсказание сети")
-
-	loadToNetworkBtn := widget.NewButton("Получить предсказание", func() {
-		input := grid.getDataForPredict()
-		output := network.Forward(input)
-		prediction := ArgMax(output)
-		confidence := output[prediction]
-
-		label.SetText(fmt.Sprintf("Нейронная сеть думает, что это цифра - %d \n Она уверрена в этом на %.2f%%", prediction, confidence*100))
-	})
-	w.SetContent(container.NewHBox(container.NewVBox(
-		grid,
-		clearBtn,
-	),
-		loadToNetworkBtn,
-		label,
-	))
-	w.Resize(fyne.NewSize(GridSize*PixelSize+10, GridSize*PixelSize+10))
-
-	if desk, ok := a.Driver().(desktop.Driver); ok {
-		_ = desk // desktop events if needed
-	}
-
-	w.ShowAndRun()
+	RunGUI(NewModel(network, "mnist_model.json"))
 }
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Metrics метрики сервера предсказаний в формате Prometheus. Распределение классов
+// и уверенности по моделям позволяет заметить, что рисунки пользователей перестали
+// походить на MNIST: растет доля неуверенных ответов и смещаются частоты классов
+type Metrics struct {
+	registry      *prometheus.Registry
+	requests      *prometheus.CounterVec
+	duration      *prometheus.HistogramVec
+	predictions   *prometheus.CounterVec
+	confidence    *prometheus.HistogramVec
+	lowConfidence *prometheus.CounterVec
+	threshold     float64 // Ответы с уверенностью ниже порога считаются неуверенными
+}
+
+// NewMetrics создает метрики для моделей реестра models
+func NewMetrics(models *Registry, threshold float64) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mnist_requests_total",
+			Help: "Количество запросов по протоколу, обработчику и коду ответа.",
+		}, []string{"protocol", "handler", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "mnist_request_duration_seconds",
+			Help:    "Время обработки запросов.",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"protocol", "handler"}),
+		predictions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mnist_predictions_total",
+			Help: "Количество предсказаний по моделям и предсказанным классам.",
+		}, []string{"model", "class"}),
+		confidence: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "mnist_prediction_confidence",
+			Help:    "Вероятность предсказанного класса.",
+			Buckets: prometheus.LinearBuckets(0.1, 0.1, 10),
+		}, []string{"model"}),
+		lowConfidence: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mnist_low_confidence_predictions_total",
+			Help: "Количество предсказаний с уверенностью ниже порога -low-confidence.",
+		}, []string{"model"}),
+		threshold: threshold,
+	}
+
+	m.registry.MustRegister(
+		m.requests, m.duration, m.predictions, m.confidence, m.lowConfidence,
+		batchCollector{models},
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler возвращает обработчик /metrics
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// observePrediction учитывает ответ модели model
+func (m *Metrics) observePrediction(model string, output []float64) {
+	prediction := ArgMax(output)
+	confidence := output[prediction]
+
+	m.predictions.WithLabelValues(model, strconv.Itoa(prediction)).Inc()
+	m.confidence.WithLabelValues(model).Observe(confidence)
+	if confidence < m.threshold {
+		m.lowConfidence.WithLabelValues(model).Inc()
+	}
+}
+
+// observeRequest учитывает обработанный запрос и пишет его в журнал
+func (m *Metrics) observeRequest(protocol, handler, code string, duration time.Duration, attrs ...slog.Attr) {
+	m.requests.WithLabelValues(protocol, handler, code).Inc()
+	m.duration.WithLabelValues(protocol, handler).Observe(duration.Seconds())
+	logRequest(protocol, handler, code, duration, attrs...)
+}
+
+// logRequest пишет обработанный запрос в журнал
+func logRequest(protocol, handler, code string, duration time.Duration, attrs ...slog.Attr) {
+	attrs = append([]slog.Attr{
+		slog.String("protocol", protocol),
+		slog.String("handler", handler),
+		slog.String("code", code),
+		slog.Float64("duration_ms", float64(duration.Microseconds())/1000),
+	}, attrs...)
+	slog.LogAttrs(context.Background(), slog.LevelInfo, "request", attrs...)
+}
+
+// Middleware считает HTTP-запросы и пишет их в журнал. Обработчиком считается шаблон
+// маршрута ServeMux, чтобы число меток не зависело от адресов запросов. Для соединений,
+// перехваченных через Hijack (WebSocket), длительность — это время всего сеанса,
+// поэтому в гистограмму времени обработки она не попадает
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		handler := r.Pattern
+		if handler == "" {
+			handler = "unmatched"
+		}
+		code, duration := strconv.Itoa(recorder.status), time.Since(start)
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("model", r.URL.Query().Get("model")),
+			slog.String("remote", r.RemoteAddr),
+			slog.Int("bytes", recorder.bytes),
+		}
+		if recorder.hijacked {
+			m.requests.WithLabelValues("http", handler, code).Inc()
+			logRequest("http", handler, code, duration, attrs...)
+			return
+		}
+		m.observeRequest("http", handler, code, duration, attrs...)
+	})
+}
+
+// UnaryInterceptor считает унарные вызовы gRPC
+func (m *Metrics) UnaryInterceptor(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	response, err := handler(ctx, request)
+	m.observeRequest("grpc", info.FullMethod, status.Code(err).String(), time.Since(start))
+	return response, err
+}
+
+// StreamInterceptor считает потоковые вызовы gRPC
+func (m *Metrics) StreamInterceptor(server any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(server, stream)
+	m.observeRequest("grpc", info.FullMethod, status.Code(err).String(), time.Since(start))
+	return err
+}
+
+// statusRecorder запоминает код и размер ответа. Поддерживает Hijack для WebSocket
+type statusRecorder struct {
+	http.ResponseWriter
+	status   int
+	bytes    int
+	hijacked bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(data []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(data)
+	r.bytes += n
+	return n, err
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("соединение не поддерживает Hijack")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		r.status = http.StatusSwitchingProtocols
+		r.hijacked = true
+	}
+	return conn, rw, err
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// batchCollector отдает статистику батчей реестра как гистограмму mnist_batch_size
+type batchCollector struct {
+	models *Registry
+}
+
+var batchSizeDesc = prometheus.NewDesc("mnist_batch_size",
+	"Размеры выполненных батчей предсказаний.", []string{"model"}, nil)
+
+func (c batchCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- batchSizeDesc
+}
+
+func (c batchCollector) Collect(ch chan<- prometheus.Metric) {
+	for name, stats := range c.models.BatchStats() {
+		// Границы корзин — степени двойки до наибольшего размера батча
+		buckets := make(map[float64]uint64)
+		for bound := 1; ; bound *= 2 {
+			bound = min(bound, stats.MaxBatch)
+			var count uint64
+			for size, batches := range stats.Sizes {
+				if size <= bound {
+					count += uint64(batches)
+				}
+			}
+			buckets[float64(bound)] = count
+			if bound == stats.MaxBatch {
+				break
+			}
+		}
+		ch <- prometheus.MustNewConstHistogram(batchSizeDesc,
+			uint64(stats.Batches), float64(stats.Requests), buckets, name)
+	}
+}
@@ -1,57 +1,164 @@
 // Используем готовый датасет через Python и сохраняем в бинарном формате
 package main
 
-import "os"
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
 
 // LoadMNISTFromBin загружает данные из бинарных файлов
 func LoadMNISTFromBin() ([][]float64, []int, [][]float64, []int, error) {
 	// Чтение тренировочных изображений
-	trainImagesData, err := os.ReadFile("data/train-images.bin")
+	train, err := LoadBinDataset("data/train")
 	if err != nil {
 		return nil, nil, nil, nil, err
 	}
 
-	trainLabelsData, err := os.ReadFile("data/train-labels.bin")
+	// Чтение тестовых изображений
+	test, err := LoadBinDataset("data/test")
 	if err != nil {
 		return nil, nil, nil, nil, err
 	}
 
-	// Чтение тестовых изображений
-	testImagesData, err := os.ReadFile("data/test-images.bin")
+	return train.Images, train.Labels, test.Images, test.Labels, nil
+}
+
+// LoadBinDataset загружает пару файлов <prefix>-images.bin и <prefix>-labels.bin
+// (по одному байту на пиксель и на метку, без заголовков)
+func LoadBinDataset(prefix string) (*Dataset, error) {
+	imagesData, err := os.ReadFile(prefix + "-images.bin")
 	if err != nil {
-		return nil, nil, nil, nil, err
+		return nil, err
 	}
 
-	testLabelsData, err := os.ReadFile("data/test-labels.bin")
+	labelsData, err := os.ReadFile(prefix + "-labels.bin")
 	if err != nil {
-		return nil, nil, nil, nil, err
+		return nil, err
+	}
+
+	return datasetFromBytes(imagesData, labelsData)
+}
+
+// SaveBinDataset сохраняет набор в формате <prefix>-images.bin и <prefix>-labels.bin
+func SaveBinDataset(d *Dataset, prefix string) error {
+	imagesData, labelsData, err := datasetToBytes(d)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(prefix+"-images.bin", imagesData, 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(prefix+"-labels.bin", labelsData, 0644)
+}
+
+// AppendBinDataset дописывает примеры в конец файлов <prefix>-images.bin и <prefix>-labels.bin,
+// создавая их при необходимости. Если запись не удалась, оба файла возвращаются к прежнему размеру,
+// чтобы изображения и метки не разошлись
+func AppendBinDataset(d *Dataset, prefix string) error {
+	imagesData, labelsData, err := datasetToBytes(d)
+	if err != nil {
+		return err
 	}
 
-	// Конвертация тренировочных данных
-	numTrain := 60000
-	trainImages := make([][]float64, numTrain)
-	trainLabels := make([]int, numTrain)
+	imagesFile, labelsFile := prefix+"-images.bin", prefix+"-labels.bin"
+	imagesSize, err := fileSize(imagesFile)
+	if err != nil {
+		return err
+	}
+	labelsSize, err := fileSize(labelsFile)
+	if err != nil {
+		return err
+	}
+	if imagesSize != labelsSize*ImagePixels {
+		return fmt.Errorf("%s: размер изображений (%d байт) не соответствует количеству меток (%d)",
+			prefix, imagesSize, labelsSize)
+	}
 
-	for i := 0; i < numTrain; i++ {
-		trainImages[i] = make([]float64, 784)
-		for j := 0; j < 784; j++ {
-			trainImages[i][j] = float64(trainImagesData[i*784+j]) / 255.0
+	err = appendFile(imagesFile, imagesData)
+	if err == nil {
+		err = appendFile(labelsFile, labelsData)
+	}
+	if err != nil {
+		os.Truncate(imagesFile, imagesSize)
+		os.Truncate(labelsFile, labelsSize)
+		return err
+	}
+	return nil
+}
+
+// fileSize возвращает размер файла, для отсутствующего файла — 0
+func fileSize(filename string) (int64, error) {
+	info, err := os.Stat(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func appendFile(filename string, data []byte) error {
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// datasetFromBytes конвертирует байты (0..255) в изображения (0..1)
+func datasetFromBytes(imagesData, labelsData []byte) (*Dataset, error) {
+	num := len(labelsData)
+	if len(imagesData) != num*ImagePixels {
+		return nil, fmt.Errorf("размер изображений (%d байт) не соответствует количеству меток (%d)",
+			len(imagesData), num)
+	}
+
+	dataset := &Dataset{
+		Images: make([][]float64, num),
+		Labels: make([]int, num),
+	}
+
+	for i := 0; i < num; i++ {
+		dataset.Images[i] = make([]float64, ImagePixels)
+		for j := 0; j < ImagePixels; j++ {
+			dataset.Images[i][j] = float64(imagesData[i*ImagePixels+j]) / PixelMax
 		}
-		trainLabels[i] = int(trainLabelsData[i])
+		dataset.Labels[i] = int(labelsData[i])
 	}
 
-	// Конвертация тестовых данных
-	numTest := 10000
-	testImages := make([][]float64, numTest)
-	testLabels := make([]int, numTest)
+	return dataset, nil
+}
+
+// datasetToBytes конвертирует изображения (0..1) в байты (0..255).
+// Метки вне диапазона 0..9 не помещаются в формат и считаются ошибкой
+func datasetToBytes(d *Dataset) ([]byte, []byte, error) {
+	imagesData := make([]byte, d.Len()*ImagePixels)
+	labelsData := make([]byte, d.Len())
 
-	for i := 0; i < numTest; i++ {
-		testImages[i] = make([]float64, 784)
-		for j := 0; j < 784; j++ {
-			testImages[i][j] = float64(testImagesData[i*784+j]) / 255.0
+	for i, image := range d.Images {
+		for j, value := range image {
+			imagesData[i*ImagePixels+j] = pixelToByte(value)
+		}
+		if d.Labels[i] < 0 || d.Labels[i] > 9 {
+			return nil, nil, fmt.Errorf("пример %d: метка %d вне диапазона 0..9", i, d.Labels[i])
 		}
-		testLabels[i] = int(testLabelsData[i])
+		labelsData[i] = byte(d.Labels[i])
 	}
 
-	return trainImages, trainLabels, testImages, testLabels, nil
+	return imagesData, labelsData, nil
+}
+
+// pixelToByte переводит значение 0..1 в байт 0..255
+func pixelToByte(value float64) byte {
+	value = min(max(value, 0), 1)
+	return byte(value*PixelMax + 0.5)
 }
@@ -0,0 +1,582 @@
+// Сервис предсказаний цифр MNIST. Go-код в каталоге mnistpb генерируется командой
+// go generate (нужны protoc, protoc-gen-go и protoc-gen-go-grpc)
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: mnist.proto
+
+package mnistpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// PredictRequest изображение: 784 пикселя 28x28 (0..1, белая цифра на черном фоне)
+// или PNG/JPEG, который нормализуется как в MNIST. model — имя модели реестра,
+// пусто — модель по умолчанию; в PredictBatch учитывается только первое сообщение
+type PredictRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Input:
+	//
+	//	*PredictRequest_Pixels
+	//	*PredictRequest_Image
+	Input         isPredictRequest_Input `protobuf_oneof:"input"`
+	Model         string                 `protobuf:"bytes,3,opt,name=model,proto3" json:"model,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PredictRequest) Reset() {
+	*x = PredictRequest{}
+	mi := &file_mnist_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PredictRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PredictRequest) ProtoMessage() {}
+
+func (x *PredictRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mnist_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PredictRequest.ProtoReflect.Descriptor instead.
+func (*PredictRequest) Descriptor() ([]byte, []int) {
+	return file_mnist_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PredictRequest) GetInput() isPredictRequest_Input {
+	if x != nil {
+		return x.Input
+	}
+	return nil
+}
+
+func (x *PredictRequest) GetPixels() *Pixels {
+	if x != nil {
+		if x, ok := x.Input.(*PredictRequest_Pixels); ok {
+			return x.Pixels
+		}
+	}
+	return nil
+}
+
+func (x *PredictRequest) GetImage() []byte {
+	if x != nil {
+		if x, ok := x.Input.(*PredictRequest_Image); ok {
+			return x.Image
+		}
+	}
+	return nil
+}
+
+func (x *PredictRequest) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+type isPredictRequest_Input interface {
+	isPredictRequest_Input()
+}
+
+type PredictRequest_Pixels struct {
+	Pixels *Pixels `protobuf:"bytes,1,opt,name=pixels,proto3,oneof"`
+}
+
+type PredictRequest_Image struct {
+	Image []byte `protobuf:"bytes,2,opt,name=image,proto3,oneof"`
+}
+
+func (*PredictRequest_Pixels) isPredictRequest_Input() {}
+
+func (*PredictRequest_Image) isPredictRequest_Input() {}
+
+type Pixels struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []float32              `protobuf:"fixed32,1,rep,packed,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Pixels) Reset() {
+	*x = Pixels{}
+	mi := &file_mnist_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Pixels) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Pixels) ProtoMessage() {}
+
+func (x *Pixels) ProtoReflect() protoreflect.Message {
+	mi := &file_mnist_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Pixels.ProtoReflect.Descriptor instead.
+func (*Pixels) Descriptor() ([]byte, []int) {
+	return file_mnist_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Pixels) GetValues() []float32 {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+// PredictResponse результат предсказания
+type PredictResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prediction    int32                  `protobuf:"varint,1,opt,name=prediction,proto3" json:"prediction,omitempty"`
+	Confidence    float64                `protobuf:"fixed64,2,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	Probabilities []float64              `protobuf:"fixed64,3,rep,packed,name=probabilities,proto3" json:"probabilities,omitempty"`
+	Model         string                 `protobuf:"bytes,4,opt,name=model,proto3" json:"model,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PredictResponse) Reset() {
+	*x = PredictResponse{}
+	mi := &file_mnist_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PredictResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PredictResponse) ProtoMessage() {}
+
+func (x *PredictResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mnist_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PredictResponse.ProtoReflect.Descriptor instead.
+func (*PredictResponse) Descriptor() ([]byte, []int) {
+	return file_mnist_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PredictResponse) GetPrediction() int32 {
+	if x != nil {
+		return x.Prediction
+	}
+	return 0
+}
+
+func (x *PredictResponse) GetConfidence() float64 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+func (x *PredictResponse) GetProbabilities() []float64 {
+	if x != nil {
+		return x.Probabilities
+	}
+	return nil
+}
+
+func (x *PredictResponse) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+type PredictBatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Predictions   []*PredictResponse     `protobuf:"bytes,1,rep,name=predictions,proto3" json:"predictions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PredictBatchResponse) Reset() {
+	*x = PredictBatchResponse{}
+	mi := &file_mnist_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PredictBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PredictBatchResponse) ProtoMessage() {}
+
+func (x *PredictBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mnist_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PredictBatchResponse.ProtoReflect.Descriptor instead.
+func (*PredictBatchResponse) Descriptor() ([]byte, []int) {
+	return file_mnist_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PredictBatchResponse) GetPredictions() []*PredictResponse {
+	if x != nil {
+		return x.Predictions
+	}
+	return nil
+}
+
+// Stroke штрих: координаты точек на холсте side x side и, если известно,
+// время каждой точки в миллисекундах от начала рисунка
+type Stroke struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	X             []float32              `protobuf:"fixed32,1,rep,packed,name=x,proto3" json:"x,omitempty"`
+	Y             []float32              `protobuf:"fixed32,2,rep,packed,name=y,proto3" json:"y,omitempty"`
+	T             []int64                `protobuf:"varint,3,rep,packed,name=t,proto3" json:"t,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Stroke) Reset() {
+	*x = Stroke{}
+	mi := &file_mnist_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Stroke) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Stroke) ProtoMessage() {}
+
+func (x *Stroke) ProtoReflect() protoreflect.Message {
+	mi := &file_mnist_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Stroke.ProtoReflect.Descriptor instead.
+func (*Stroke) Descriptor() ([]byte, []int) {
+	return file_mnist_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Stroke) GetX() []float32 {
+	if x != nil {
+		return x.X
+	}
+	return nil
+}
+
+func (x *Stroke) GetY() []float32 {
+	if x != nil {
+		return x.Y
+	}
+	return nil
+}
+
+func (x *Stroke) GetT() []int64 {
+	if x != nil {
+		return x.T
+	}
+	return nil
+}
+
+// LiveRequest рисунок по штрихам, всего не больше 10000 точек. Координаты
+// за пределами полосы шириной side вокруг холста прижимаются к ней
+type LiveRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Strokes []*Stroke              `protobuf:"bytes,1,rep,name=strokes,proto3" json:"strokes,omitempty"`
+	// Сторона холста в единицах координат, не меньше 1, по умолчанию 280
+	Side float32 `protobuf:"fixed32,2,opt,name=side,proto3" json:"side,omitempty"`
+	// Через сколько точек отправлять предсказание; 0 — после каждого штриха
+	Every uint32 `protobuf:"varint,3,opt,name=every,proto3" json:"every,omitempty"`
+	// Имя модели реестра, пусто — модель по умолчанию
+	Model         string `protobuf:"bytes,4,opt,name=model,proto3" json:"model,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LiveRequest) Reset() {
+	*x = LiveRequest{}
+	mi := &file_mnist_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LiveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LiveRequest) ProtoMessage() {}
+
+func (x *LiveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mnist_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LiveRequest.ProtoReflect.Descriptor instead.
+func (*LiveRequest) Descriptor() ([]byte, []int) {
+	return file_mnist_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *LiveRequest) GetStrokes() []*Stroke {
+	if x != nil {
+		return x.Strokes
+	}
+	return nil
+}
+
+func (x *LiveRequest) GetSide() float32 {
+	if x != nil {
+		return x.Side
+	}
+	return 0
+}
+
+func (x *LiveRequest) GetEvery() uint32 {
+	if x != nil {
+		return x.Every
+	}
+	return 0
+}
+
+func (x *LiveRequest) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+// LivePrediction предсказание после точки point штриха stroke (индексы с нуля)
+type LivePrediction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stroke        int32                  `protobuf:"varint,1,opt,name=stroke,proto3" json:"stroke,omitempty"`
+	Point         int32                  `protobuf:"varint,2,opt,name=point,proto3" json:"point,omitempty"`
+	Prediction    *PredictResponse       `protobuf:"bytes,3,opt,name=prediction,proto3" json:"prediction,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LivePrediction) Reset() {
+	*x = LivePrediction{}
+	mi := &file_mnist_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LivePrediction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LivePrediction) ProtoMessage() {}
+
+func (x *LivePrediction) ProtoReflect() protoreflect.Message {
+	mi := &file_mnist_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LivePrediction.ProtoReflect.Descriptor instead.
+func (*LivePrediction) Descriptor() ([]byte, []int) {
+	return file_mnist_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *LivePrediction) GetStroke() int32 {
+	if x != nil {
+		return x.Stroke
+	}
+	return 0
+}
+
+func (x *LivePrediction) GetPoint() int32 {
+	if x != nil {
+		return x.Point
+	}
+	return 0
+}
+
+func (x *LivePrediction) GetPrediction() *PredictResponse {
+	if x != nil {
+		return x.Prediction
+	}
+	return nil
+}
+
+var File_mnist_proto protoreflect.FileDescriptor
+
+const file_mnist_proto_rawDesc = "" +
+	"\n" +
+	"\vmnist.proto\x12\bmnist.v1\"s\n" +
+	"\x0ePredictRequest\x12*\n" +
+	"\x06pixels\x18\x01 \x01(\v2\x10.mnist.v1.PixelsH\x00R\x06pixels\x12\x16\n" +
+	"\x05image\x18\x02 \x01(\fH\x00R\x05image\x12\x14\n" +
+	"\x05model\x18\x03 \x01(\tR\x05modelB\a\n" +
+	"\x05input\" \n" +
+	"\x06Pixels\x12\x16\n" +
+	"\x06values\x18\x01 \x03(\x02R\x06values\"\x8d\x01\n" +
+	"\x0fPredictResponse\x12\x1e\n" +
+	"\n" +
+	"prediction\x18\x01 \x01(\x05R\n" +
+	"prediction\x12\x1e\n" +
+	"\n" +
+	"confidence\x18\x02 \x01(\x01R\n" +
+	"confidence\x12$\n" +
+	"\rprobabilities\x18\x03 \x03(\x01R\rprobabilities\x12\x14\n" +
+	"\x05model\x18\x04 \x01(\tR\x05model\"S\n" +
+	"\x14PredictBatchResponse\x12;\n" +
+	"\vpredictions\x18\x01 \x03(\v2\x19.mnist.v1.PredictResponseR\vpredictions\"2\n" +
+	"\x06Stroke\x12\f\n" +
+	"\x01x\x18\x01 \x03(\x02R\x01x\x12\f\n" +
+	"\x01y\x18\x02 \x03(\x02R\x01y\x12\f\n" +
+	"\x01t\x18\x03 \x03(\x03R\x01t\"y\n" +
+	"\vLiveRequest\x12*\n" +
+	"\astrokes\x18\x01 \x03(\v2\x10.mnist.v1.StrokeR\astrokes\x12\x12\n" +
+	"\x04side\x18\x02 \x01(\x02R\x04side\x12\x14\n" +
+	"\x05every\x18\x03 \x01(\rR\x05every\x12\x14\n" +
+	"\x05model\x18\x04 \x01(\tR\x05model\"y\n" +
+	"\x0eLivePrediction\x12\x16\n" +
+	"\x06stroke\x18\x01 \x01(\x05R\x06stroke\x12\x14\n" +
+	"\x05point\x18\x02 \x01(\x05R\x05point\x129\n" +
+	"\n" +
+	"prediction\x18\x03 \x01(\v2\x19.mnist.v1.PredictResponseR\n" +
+	"prediction2\xd9\x01\n" +
+	"\tPredictor\x12>\n" +
+	"\aPredict\x12\x18.mnist.v1.PredictRequest\x1a\x19.mnist.v1.PredictResponse\x12J\n" +
+	"\fPredictBatch\x12\x18.mnist.v1.PredictRequest\x1a\x1e.mnist.v1.PredictBatchResponse(\x01\x12@\n" +
+	"\vPredictLive\x12\x15.mnist.v1.LiveRequest\x1a\x18.mnist.v1.LivePrediction0\x01B!Z\x1fgithub.com/igntnk/mnist/mnistpbb\x06proto3"
+
+var (
+	file_mnist_proto_rawDescOnce sync.Once
+	file_mnist_proto_rawDescData []byte
+)
+
+func file_mnist_proto_rawDescGZIP() []byte {
+	file_mnist_proto_rawDescOnce.Do(func() {
+		file_mnist_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_mnist_proto_rawDesc), len(file_mnist_proto_rawDesc)))
+	})
+	return file_mnist_proto_rawDescData
+}
+
+var file_mnist_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_mnist_proto_goTypes = []any{
+	(*PredictRequest)(nil),       // 0: mnist.v1.PredictRequest
+	(*Pixels)(nil),               // 1: mnist.v1.Pixels
+	(*PredictResponse)(nil),      // 2: mnist.v1.PredictResponse
+	(*PredictBatchResponse)(nil), // 3: mnist.v1.PredictBatchResponse
+	(*Stroke)(nil),               // 4: mnist.v1.Stroke
+	(*LiveRequest)(nil),          // 5: mnist.v1.LiveRequest
+	(*LivePrediction)(nil),       // 6: mnist.v1.LivePrediction
+}
+var file_mnist_proto_depIdxs = []int32{
+	1, // 0: mnist.v1.PredictRequest.pixels:type_name -> mnist.v1.Pixels
+	2, // 1: mnist.v1.PredictBatchResponse.predictions:type_name -> mnist.v1.PredictResponse
+	4, // 2: mnist.v1.LiveRequest.strokes:type_name -> mnist.v1.Stroke
+	2, // 3: mnist.v1.LivePrediction.prediction:type_name -> mnist.v1.PredictResponse
+	0, // 4: mnist.v1.Predictor.Predict:input_type -> mnist.v1.PredictRequest
+	0, // 5: mnist.v1.Predictor.PredictBatch:input_type -> mnist.v1.PredictRequest
+	5, // 6: mnist.v1.Predictor.PredictLive:input_type -> mnist.v1.LiveRequest
+	2, // 7: mnist.v1.Predictor.Predict:output_type -> mnist.v1.PredictResponse
+	3, // 8: mnist.v1.Predictor.PredictBatch:output_type -> mnist.v1.PredictBatchResponse
+	6, // 9: mnist.v1.Predictor.PredictLive:output_type -> mnist.v1.LivePrediction
+	7, // [7:10] is the sub-list for method output_type
+	4, // [4:7] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_mnist_proto_init() }
+func file_mnist_proto_init() {
+	if File_mnist_proto != nil {
+		return
+	}
+	file_mnist_proto_msgTypes[0].OneofWrappers = []any{
+		(*PredictRequest_Pixels)(nil),
+		(*PredictRequest_Image)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_mnist_proto_rawDesc), len(file_mnist_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_mnist_proto_goTypes,
+		DependencyIndexes: file_mnist_proto_depIdxs,
+		MessageInfos:      file_mnist_proto_msgTypes,
+	}.Build()
+	File_mnist_proto = out.File
+	file_mnist_proto_goTypes = nil
+	file_mnist_proto_depIdxs = nil
+}
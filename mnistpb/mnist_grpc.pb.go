@@ -0,0 +1,207 @@
+// Сервис предсказаний цифр MNIST. Go-код в каталоге mnistpb генерируется командой
+// go generate (нужны protoc, protoc-gen-go и protoc-gen-go-grpc)
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: mnist.proto
+
+package mnistpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Predictor_Predict_FullMethodName      = "/mnist.v1.Predictor/Predict"
+	Predictor_PredictBatch_FullMethodName = "/mnist.v1.Predictor/PredictBatch"
+	Predictor_PredictLive_FullMethodName  = "/mnist.v1.Predictor/PredictLive"
+)
+
+// PredictorClient is the client API for Predictor service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PredictorClient interface {
+	// Predict предсказание для одного изображения
+	Predict(ctx context.Context, in *PredictRequest, opts ...grpc.CallOption) (*PredictResponse, error)
+	// PredictBatch принимает поток изображений и после закрытия потока
+	// возвращает предсказания для всех одним батчем в том же порядке
+	PredictBatch(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[PredictRequest, PredictBatchResponse], error)
+	// PredictLive рисует штрихи по порядку и после каждого обновления рисунка
+	// возвращает предсказание для уже нарисованной части
+	PredictLive(ctx context.Context, in *LiveRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LivePrediction], error)
+}
+
+type predictorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPredictorClient(cc grpc.ClientConnInterface) PredictorClient {
+	return &predictorClient{cc}
+}
+
+func (c *predictorClient) Predict(ctx context.Context, in *PredictRequest, opts ...grpc.CallOption) (*PredictResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PredictResponse)
+	err := c.cc.Invoke(ctx, Predictor_Predict_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *predictorClient) PredictBatch(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[PredictRequest, PredictBatchResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Predictor_ServiceDesc.Streams[0], Predictor_PredictBatch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[PredictRequest, PredictBatchResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Predictor_PredictBatchClient = grpc.ClientStreamingClient[PredictRequest, PredictBatchResponse]
+
+func (c *predictorClient) PredictLive(ctx context.Context, in *LiveRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LivePrediction], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Predictor_ServiceDesc.Streams[1], Predictor_PredictLive_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[LiveRequest, LivePrediction]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Predictor_PredictLiveClient = grpc.ServerStreamingClient[LivePrediction]
+
+// PredictorServer is the server API for Predictor service.
+// All implementations must embed UnimplementedPredictorServer
+// for forward compatibility.
+type PredictorServer interface {
+	// Predict предсказание для одного изображения
+	Predict(context.Context, *PredictRequest) (*PredictResponse, error)
+	// PredictBatch принимает поток изображений и после закрытия потока
+	// возвращает предсказания для всех одним батчем в том же порядке
+	PredictBatch(grpc.ClientStreamingServer[PredictRequest, PredictBatchResponse]) error
+	// PredictLive рисует штрихи по порядку и после каждого обновления рисунка
+	// возвращает предсказание для уже нарисованной части
+	PredictLive(*LiveRequest, grpc.ServerStreamingServer[LivePrediction]) error
+	mustEmbedUnimplementedPredictorServer()
+}
+
+// UnimplementedPredictorServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPredictorServer struct{}
+
+func (UnimplementedPredictorServer) Predict(context.Context, *PredictRequest) (*PredictResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Predict not implemented")
+}
+func (UnimplementedPredictorServer) PredictBatch(grpc.ClientStreamingServer[PredictRequest, PredictBatchResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method PredictBatch not implemented")
+}
+func (UnimplementedPredictorServer) PredictLive(*LiveRequest, grpc.ServerStreamingServer[LivePrediction]) error {
+	return status.Errorf(codes.Unimplemented, "method PredictLive not implemented")
+}
+func (UnimplementedPredictorServer) mustEmbedUnimplementedPredictorServer() {}
+func (UnimplementedPredictorServer) testEmbeddedByValue()                   {}
+
+// UnsafePredictorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PredictorServer will
+// result in compilation errors.
+type UnsafePredictorServer interface {
+	mustEmbedUnimplementedPredictorServer()
+}
+
+func RegisterPredictorServer(s grpc.ServiceRegistrar, srv PredictorServer) {
+	// If the following call pancis, it indicates UnimplementedPredictorServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Predictor_ServiceDesc, srv)
+}
+
+func _Predictor_Predict_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PredictRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PredictorServer).Predict(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Predictor_Predict_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PredictorServer).Predict(ctx, req.(*PredictRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Predictor_PredictBatch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(PredictorServer).PredictBatch(&grpc.GenericServerStream[PredictRequest, PredictBatchResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Predictor_PredictBatchServer = grpc.ClientStreamingServer[PredictRequest, PredictBatchResponse]
+
+func _Predictor_PredictLive_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(LiveRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PredictorServer).PredictLive(m, &grpc.GenericServerStream[LiveRequest, LivePrediction]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Predictor_PredictLiveServer = grpc.ServerStreamingServer[LivePrediction]
+
+// Predictor_ServiceDesc is the grpc.ServiceDesc for Predictor service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Predictor_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "mnist.v1.Predictor",
+	HandlerType: (*PredictorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Predict",
+			Handler:    _Predictor_Predict_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "PredictBatch",
+			Handler:       _Predictor_PredictBatch_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "PredictLive",
+			Handler:       _Predictor_PredictLive_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "mnist.proto",
+}
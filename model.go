@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Model потокобезопасная ссылка на текущую сеть.
+// Сеть внутри считается неизменяемой: для обновления публикуется новая копия через Set
+type Model struct {
+	mu      sync.RWMutex
+	network *Network
+	source  string
+}
+
+// NewModel создает ссылку на сеть, source — откуда она получена (файл или описание)
+func NewModel(network *Network, source string) *Model {
+	return &Model{network: network, source: source}
+}
+
+// Network возвращает текущую сеть (может быть nil)
+func (m *Model) Network() *Network {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.network
+}
+
+// Source возвращает происхождение текущей сети
+func (m *Model) Source() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.source
+}
+
+// Set заменяет текущую сеть
+func (m *Model) Set(network *Network, source string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.network = network
+	m.source = source
+}
+
+// Replace заменяет сеть old на updated с тем же происхождением, если old все еще текущая
+func (m *Model) Replace(old, updated *Network) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.network != old {
+		return false
+	}
+	m.network = updated
+	return true
+}
+
+// Describe возвращает строку с архитектурой и точностью сети
+func (m *Model) Describe() string {
+	network, source := m.Network(), m.Source()
+	if network == nil {
+		return "Модель не загружена"
+	}
+
+	accuracy := "неизвестна"
+	if network.TestAccuracy > 0 {
+		accuracy = fmt.Sprintf("%.2f%%", network.TestAccuracy*100)
+	}
+
+	return fmt.Sprintf("Модель: %s | Архитектура: %s | Тестовая точность: %s",
+		source, formatArchitecture(network.Architecture()), accuracy)
+}
+
+// formatArchitecture возвращает архитектуру в виде 784-128-64-10
+func formatArchitecture(architecture []int) string {
+	parts := make([]string, len(architecture))
+	for i, size := range architecture {
+		parts[i] = fmt.Sprint(size)
+	}
+	return strings.Join(parts, "-")
+}
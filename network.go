@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"math"
 	"math/rand"
 	"os"
@@ -25,6 +26,8 @@ type Layer struct {
 type Network struct {
 	Layers        []*Layer             `json:"layers"`
 	LearningRate  float64              `json:"learning_rate"`
+	Transforms    Pipeline             `json:"transforms,omitempty"` // Предобработка входа
+	TestAccuracy  float64              `json:"test_accuracy,omitempty"`
 	Activation    ActivationFunction   `json:"-"`
 	ActivationDer ActivationDerivative `json:"-"`
 }
@@ -65,6 +68,87 @@ func NewNetwork(architecture []int) *Network {
 	return network
 }
 
+// LoadNetwork загружает сеть из файла, сохраненного методом Save
+func LoadNetwork(filename string) (*Network, error) {
+	network := &Network{
+		Activation:    Sigmoid,
+		ActivationDer: SigmoidDerivative,
+	}
+	if err := network.Load(filename); err != nil {
+		return nil, err
+	}
+
+	if err := network.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	return network, nil
+}
+
+// Clone возвращает независимую копию сети (без промежуточных значений обучения)
+func (n *Network) Clone() *Network {
+	clone := *n
+	clone.Layers = make([]*Layer, len(n.Layers))
+	for i, layer := range n.Layers {
+		weights := make([][]float64, len(layer.Weights))
+		for j, row := range layer.Weights {
+			weights[j] = append([]float64(nil), row...)
+		}
+		clone.Layers[i] = &Layer{
+			Weights: weights,
+			Biases:  append([]float64(nil), layer.Biases...),
+		}
+	}
+	clone.Transforms = append(Pipeline(nil), n.Transforms...)
+	return &clone
+}
+
+// Validate проверяет, что загруженная сеть согласована: у каждого слоя столько же
+// смещений, сколько нейронов, а ширина строк весов равна выходу предыдущего слоя
+// (для первого — размеру входа после Transforms)
+func (n *Network) Validate() error {
+	if err := n.Transforms.Validate(); err != nil {
+		return err
+	}
+	if len(n.Layers) == 0 {
+		return fmt.Errorf("в модели нет слоев")
+	}
+
+	inputs := n.Transforms.InputSize()
+	for i, layer := range n.Layers {
+		if layer == nil || len(layer.Weights) == 0 {
+			return fmt.Errorf("слой %d: нет нейронов", i)
+		}
+		if len(layer.Weights) != len(layer.Biases) {
+			return fmt.Errorf("слой %d: %d нейронов, но %d смещений", i, len(layer.Weights), len(layer.Biases))
+		}
+		for j, row := range layer.Weights {
+			if len(row) != inputs {
+				return fmt.Errorf("слой %d, нейрон %d: %d весов, ожидается %d", i, j, len(row), inputs)
+			}
+		}
+		inputs = len(layer.Weights)
+	}
+	return nil
+}
+
+// Architecture возвращает размеры слоев, начиная со входа
+func (n *Network) Architecture() []int {
+	if len(n.Layers) == 0 {
+		return nil
+	}
+
+	input := n.Transforms.InputSize()
+	if len(n.Layers[0].Weights) > 0 {
+		input = len(n.Layers[0].Weights[0])
+	}
+	architecture := []int{input}
+	for _, layer := range n.Layers {
+		architecture = append(architecture, len(layer.Weights))
+	}
+	return architecture
+}
+
 // SetLearningRate устанавливает скорость обучения
 func (n *Network) SetLearningRate(lr float64) {
 	n.LearningRate = lr
@@ -113,6 +197,88 @@ func (n *Network) Forward(input []float64) []float64 {
 	return current
 }
 
+// Predict применяет предобработку модели и возвращает вероятности классов.
+// В отличие от Forward не изменяет состояние слоев, поэтому безопасен
+// для одновременного вызова из нескольких горутин
+func (n *Network) Predict(input []float64) []float64 {
+	activations := n.LayerActivations(input)
+	return activations[len(activations)-1]
+}
+
+// LayerActivations применяет предобработку и возвращает активации всех слоев,
+// последний элемент - вероятности классов. Как и Predict, не изменяет состояние слоев
+func (n *Network) LayerActivations(input []float64) [][]float64 {
+	current := n.Transforms.Apply(input)
+	result := make([][]float64, len(n.Layers))
+
+	for i, layer := range n.Layers {
+		activations := make([]float64, len(layer.Weights))
+
+		for j, weights := range layer.Weights {
+			sum := layer.Biases[j]
+			for k, value := range current {
+				sum += value * weights[k]
+			}
+
+			if i == len(n.Layers)-1 {
+				activations[j] = sum
+			} else {
+				activations[j] = n.Activation(sum)
+			}
+		}
+
+		if i == len(n.Layers)-1 {
+			activations = Softmax(activations)
+		}
+
+		result[i] = activations
+		current = activations
+	}
+
+	return result
+}
+
+// PredictBatch вычисляет вероятности классов сразу для нескольких входов.
+// Каждая строка весов проходит по всем входам батча подряд, что дешевле
+// отдельных вызовов Predict. Как и Predict, не изменяет состояние слоев
+func (n *Network) PredictBatch(inputs [][]float64) [][]float64 {
+	current := make([][]float64, len(inputs))
+	for b, input := range inputs {
+		current[b] = n.Transforms.Apply(input)
+	}
+
+	for i, layer := range n.Layers {
+		last := i == len(n.Layers)-1
+
+		next := make([][]float64, len(current))
+		for b := range next {
+			next[b] = make([]float64, len(layer.Weights))
+		}
+
+		for j, weights := range layer.Weights {
+			for b, x := range current {
+				sum := layer.Biases[j]
+				for k, value := range x {
+					sum += value * weights[k]
+				}
+				if !last {
+					sum = n.Activation(sum)
+				}
+				next[b][j] = sum
+			}
+		}
+
+		if last {
+			for b := range next {
+				next[b] = Softmax(next[b])
+			}
+		}
+		current = next
+	}
+
+	return current
+}
+
 // Backward обратное распространение ошибки
 func (n *Network) Backward(input []float64, target int) {
 	output := n.Forward(input)
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	numberCols     = 112 // Холст для числа: четыре поля MNIST в ширину
+	numberRows     = 28
+	numberCellSize = 10
+)
+
+// numberTab вкладка распознавания многозначного числа
+type numberTab struct {
+	p        *Predictor
+	grid     *DrawGrid
+	result   *widget.RichText
+	digits   *fyne.Container
+	debounce *time.Timer
+}
+
+// buildNumberTab создает широкий холст для записи числа
+func (p *Predictor) buildNumberTab() fyne.CanvasObject {
+	t := &numberTab{p: p}
+
+	t.grid = NewDrawGridSize(numberCols, numberRows, numberCellSize)
+	t.grid.BrushRadius = 1.5
+	t.grid.OnChanged = t.schedule
+
+	t.result = widget.NewRichTextFromMarkdown("## Напишите число")
+	t.digits = container.NewHBox()
+
+	clearBtn := widget.NewButton("Очистить", t.grid.Clear)
+	undoBtn := widget.NewButton("Отменить", t.grid.Undo)
+	redoBtn := widget.NewButton("Повторить", t.grid.Redo)
+	recognizeBtn := widget.NewButton("Распознать", t.recognize)
+
+	return container.NewVBox(
+		t.grid,
+		container.NewHBox(clearBtn, undoBtn, redoBtn, recognizeBtn),
+		t.result,
+		t.digits,
+	)
+}
+
+// schedule откладывает распознавание до паузы в рисовании
+func (t *numberTab) schedule() {
+	if t.debounce != nil {
+		t.debounce.Stop()
+	}
+	t.debounce = time.AfterFunc(300*time.Millisecond, func() {
+		fyne.Do(t.recognize)
+	})
+}
+
+// recognize разбивает рисунок на цифры и показывает число с уверенностью по каждой цифре
+func (t *numberTab) recognize() {
+	network := t.p.model.Network()
+	if network == nil {
+		t.result.ParseMarkdown("## Модель не загружена")
+		return
+	}
+
+	results := RecognizeNumber(network, t.grid.getDataForPredict(), numberCols, numberRows)
+	t.digits.RemoveAll()
+	if len(results) == 0 {
+		t.result.ParseMarkdown("## Напишите число")
+		return
+	}
+
+	t.result.ParseMarkdown("## Число: " + NumberString(results))
+	for _, r := range results {
+		preview := canvas.NewImageFromImage(ImageFromPixels(r.Input))
+		preview.ScaleMode = canvas.ImageScalePixels
+		preview.SetMinSize(fyne.NewSize(ImageSide*3, ImageSide*3))
+
+		t.digits.Add(container.NewVBox(
+			preview,
+			widget.NewLabel(fmt.Sprintf("%d: %.1f%%", r.Digit, r.Confidence*100)),
+		))
+	}
+}
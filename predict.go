@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// predictInput один вход команды predict: файл изображения или изображение из набора
+type predictInput struct {
+	name  string    // Файл или <набор>#<номер> для изображений из набора
+	path  string    // Файл изображения, который нужно прочитать
+	image []float64 // Уже загруженное изображение из набора
+	label int       // Метка из набора, -1 если неизвестна
+}
+
+// ClassScore вероятность одного класса
+type ClassScore struct {
+	Class       int     `json:"class"`
+	Probability float64 `json:"probability"`
+}
+
+// PredictionRecord результат команды predict для одного входа
+type PredictionRecord struct {
+	File       string       `json:"file"`
+	Label      *int         `json:"label,omitempty"`
+	Prediction int          `json:"prediction"`
+	Confidence float64      `json:"confidence"`
+	Top        []ClassScore `json:"top"`
+}
+
+// runPredict классифицирует изображения из файлов, каталогов и наборов данных
+func runPredict(args []string) error {
+	flags := flag.NewFlagSet("predict", flag.ExitOnError)
+	modelPath := flags.String("model", "mnist_model.json", "файл модели")
+	out := flags.String("out", "", "файл результатов (пусто — стандартный вывод)")
+	format := flags.String("format", "", "формат результатов: csv или jsonl (по умолчанию по расширению -out, иначе csv)")
+	topK := flags.Int("k", 3, "сколько самых вероятных классов выводить")
+	workers := flags.Int("workers", runtime.NumCPU(), "количество параллельных обработчиков")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Использование: mnist predict [флаги] <вход>...")
+		fmt.Fprintln(os.Stderr, "Вход: файл PNG/JPEG/GIF, каталог с изображениями (рекурсивно),")
+		fmt.Fprintln(os.Stderr, "файл <путь>-images.bin или набор <формат>:<путь>, как в convert")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("не указаны входы")
+	}
+
+	if *format == "" {
+		*format = "csv"
+		if ext := strings.ToLower(filepath.Ext(*out)); ext == ".jsonl" || ext == ".ndjson" {
+			*format = "jsonl"
+		}
+	}
+	if *format != "csv" && *format != "jsonl" {
+		return fmt.Errorf("неизвестный формат результатов %q, ожидается csv или jsonl", *format)
+	}
+	network, err := LoadNetwork(*modelPath)
+	if err != nil {
+		return err
+	}
+	architecture := network.Architecture()
+	k := min(max(*topK, 1), architecture[len(architecture)-1])
+
+	inputs, failed := collectPredictInputs(flags.Args())
+	records, errs := predictInputs(network, inputs, k, max(*workers, 1))
+
+	var ok []PredictionRecord
+	for i, err := range errs {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", inputs[i].name, err)
+			failed++
+			continue
+		}
+		ok = append(ok, records[i])
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	buffered := bufio.NewWriter(w)
+	if *format == "jsonl" {
+		err = writePredictionsJSONL(buffered, ok)
+	} else {
+		err = writePredictionsCSV(buffered, ok, k)
+	}
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Классифицировано изображений: %d\n", len(ok))
+	if failed > 0 {
+		return fmt.Errorf("не удалось прочитать входов: %d", failed)
+	}
+	return nil
+}
+
+// collectPredictInputs разворачивает аргументы во входы. Ошибки чтения аргументов
+// выводятся сразу, возвращается их количество
+func collectPredictInputs(args []string) ([]predictInput, int) {
+	var inputs []predictInput
+	failed := 0
+	fail := func(name string, err error) {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		failed++
+	}
+
+	for _, arg := range args {
+		if name, _, ok := strings.Cut(arg, ":"); ok {
+			if _, known := datasetFormats[name]; known {
+				dataset, err := LoadDataset(arg)
+				if err != nil {
+					fail(arg, err)
+					continue
+				}
+				inputs = append(inputs, datasetInputs(arg, dataset.Images, dataset.Labels)...)
+				continue
+			}
+		}
+
+		info, err := os.Stat(arg)
+		if err != nil {
+			fail(arg, err)
+			continue
+		}
+
+		switch {
+		case info.IsDir():
+			err := filepath.WalkDir(arg, func(path string, entry fs.DirEntry, err error) error {
+				if err != nil {
+					fail(path, err)
+					return nil
+				}
+				if !entry.IsDir() && imageExtensions[strings.ToLower(filepath.Ext(path))] {
+					inputs = append(inputs, predictInput{name: path, path: path, label: -1})
+				}
+				return nil
+			})
+			if err != nil {
+				fail(arg, err)
+			}
+
+		case strings.HasSuffix(arg, "-images.bin"):
+			images, labels, err := loadBinImages(arg)
+			if err != nil {
+				fail(arg, err)
+				continue
+			}
+			inputs = append(inputs, datasetInputs(arg, images, labels)...)
+
+		default:
+			inputs = append(inputs, predictInput{name: arg, path: arg, label: -1})
+		}
+	}
+
+	return inputs, failed
+}
+
+// datasetInputs превращает изображения набора во входы с именами <набор>#<номер>
+func datasetInputs(source string, images [][]float64, labels []int) []predictInput {
+	inputs := make([]predictInput, len(images))
+	for i, image := range images {
+		inputs[i] = predictInput{name: fmt.Sprintf("%s#%d", source, i), image: image, label: -1}
+		if labels != nil {
+			inputs[i].label = labels[i]
+		}
+	}
+	return inputs
+}
+
+// loadBinImages читает <prefix>-images.bin без обязательного файла меток.
+// Если рядом лежит <prefix>-labels.bin подходящего размера, метки тоже возвращаются
+func loadBinImages(filename string) ([][]float64, []int, error) {
+	imagesData, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(imagesData)%ImagePixels != 0 {
+		return nil, nil, fmt.Errorf("размер файла (%d байт) не кратен %d", len(imagesData), ImagePixels)
+	}
+
+	labelsData, err := os.ReadFile(strings.TrimSuffix(filename, "-images.bin") + "-labels.bin")
+	labeled := err == nil && len(labelsData)*ImagePixels == len(imagesData)
+	if !labeled {
+		labelsData = make([]byte, len(imagesData)/ImagePixels)
+	}
+
+	dataset, err := datasetFromBytes(imagesData, labelsData)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !labeled {
+		return dataset.Images, nil, nil
+	}
+	return dataset.Images, dataset.Labels, nil
+}
+
+// predictInputs читает и классифицирует входы в workers горутинах.
+// Результаты и ошибки возвращаются в порядке входов
+func predictInputs(network *Network, inputs []predictInput, k, workers int) ([]PredictionRecord, []error) {
+	records := make([]PredictionRecord, len(inputs))
+	errs := make([]error, len(inputs))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				records[i], errs[i] = predictOne(network, inputs[i], k)
+			}
+		}()
+	}
+	for i := range inputs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return records, errs
+}
+
+// predictOne классифицирует один вход
+func predictOne(network *Network, input predictInput, k int) (PredictionRecord, error) {
+	image := input.image
+	if image == nil {
+		var err error
+		if image, err = LoadImageFile(input.path); err != nil {
+			return PredictionRecord{}, err
+		}
+	}
+
+	output := network.Predict(image)
+	prediction := ArgMax(output)
+	record := PredictionRecord{
+		File:       input.name,
+		Prediction: prediction,
+		Confidence: output[prediction],
+		Top:        TopK(output, k),
+	}
+	if input.label >= 0 {
+		label := input.label
+		record.Label = &label
+	}
+	return record, nil
+}
+
+// TopK возвращает k самых вероятных классов по убыванию вероятности
+func TopK(output []float64, k int) []ClassScore {
+	scores := make([]ClassScore, len(output))
+	for class, p := range output {
+		scores[class] = ClassScore{Class: class, Probability: p}
+	}
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].Probability > scores[j].Probability
+	})
+	return scores[:min(k, len(scores))]
+}
+
+// writePredictionsCSV пишет результаты с колонками file,label,prediction,confidence,top1,p1,...
+func writePredictionsCSV(w io.Writer, records []PredictionRecord, k int) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{"file", "label", "prediction", "confidence"}
+	for i := 1; i <= k; i++ {
+		header = append(header, fmt.Sprintf("top%d", i), fmt.Sprintf("p%d", i))
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		label := ""
+		if record.Label != nil {
+			label = strconv.Itoa(*record.Label)
+		}
+		row := []string{record.File, label, strconv.Itoa(record.Prediction), formatProbability(record.Confidence)}
+		for _, score := range record.Top {
+			row = append(row, strconv.Itoa(score.Class), formatProbability(score.Probability))
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// writePredictionsJSONL пишет результаты по одному объекту JSON в строке
+func writePredictionsJSONL(w io.Writer, records []PredictionRecord) error {
+	encoder := json.NewEncoder(w)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatProbability(p float64) string {
+	return strconv.FormatFloat(p, 'f', 6, 64)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	chartBarWidth  = 36
+	chartBarHeight = 160
+	chartTextSize  = 12
+)
+
+var (
+	chartBarColor = color.RGBA{180, 180, 180, 255}
+	// Цвета трех самых вероятных классов
+	chartTopColors = []color.Color{
+		color.RGBA{46, 139, 87, 255},
+		color.RGBA{70, 130, 180, 255},
+		color.RGBA{218, 165, 32, 255},
+	}
+)
+
+// ProbabilityChart столбчатая диаграмма вероятностей десяти классов
+type ProbabilityChart struct {
+	widget.BaseWidget
+	Probabilities []float64
+}
+
+func NewProbabilityChart() *ProbabilityChart {
+	c := &ProbabilityChart{Probabilities: make([]float64, 10)}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+// SetProbabilities обновляет диаграмму
+func (c *ProbabilityChart) SetProbabilities(probabilities []float64) {
+	c.Probabilities = probabilities
+	c.Refresh()
+}
+
+// topClasses возвращает индексы классов по убыванию вероятности
+func (c *ProbabilityChart) topClasses(n int) []int {
+	indices := make([]int, len(c.Probabilities))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return c.Probabilities[indices[i]] > c.Probabilities[indices[j]]
+	})
+	return indices[:min(n, len(indices))]
+}
+
+func (c *ProbabilityChart) CreateRenderer() fyne.WidgetRenderer {
+	r := &probabilityChartRenderer{chart: c}
+	for i := 0; i < len(c.Probabilities); i++ {
+		bar := canvas.NewRectangle(chartBarColor)
+		digit := canvas.NewText(fmt.Sprint(i), theme.Color(theme.ColorNameForeground))
+		digit.Alignment = fyne.TextAlignCenter
+		digit.TextSize = chartTextSize
+		percent := canvas.NewText("", theme.Color(theme.ColorNameForeground))
+		percent.Alignment = fyne.TextAlignCenter
+		percent.TextSize = chartTextSize - 2
+
+		r.bars = append(r.bars, bar)
+		r.digits = append(r.digits, digit)
+		r.percents = append(r.percents, percent)
+		r.objects = append(r.objects, bar, digit, percent)
+	}
+	r.Refresh()
+	return r
+}
+
+type probabilityChartRenderer struct {
+	chart    *ProbabilityChart
+	bars     []*canvas.Rectangle
+	digits   []*canvas.Text
+	percents []*canvas.Text
+	objects  []fyne.CanvasObject
+}
+
+func (r *probabilityChartRenderer) Layout(size fyne.Size) {
+	barWidth := size.Width / float32(len(r.bars))
+	textHeight := float32(chartTextSize + 4)
+	maxHeight := size.Height - 2*textHeight
+
+	for i, bar := range r.bars {
+		x := float32(i) * barWidth
+		height := maxHeight * float32(r.chart.Probabilities[i])
+
+		bar.Resize(fyne.NewSize(barWidth-4, height))
+		bar.Move(fyne.NewPos(x+2, textHeight+maxHeight-height))
+
+		r.percents[i].Resize(fyne.NewSize(barWidth, textHeight))
+		r.percents[i].Move(fyne.NewPos(x, textHeight+maxHeight-height-textHeight))
+
+		r.digits[i].Resize(fyne.NewSize(barWidth, textHeight))
+		r.digits[i].Move(fyne.NewPos(x, size.Height-textHeight))
+	}
+}
+
+func (r *probabilityChartRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(chartBarWidth*float32(len(r.bars)), chartBarHeight)
+}
+
+func (r *probabilityChartRenderer) Refresh() {
+	top := r.chart.topClasses(len(chartTopColors))
+
+	for i, bar := range r.bars {
+		bar.FillColor = chartBarColor
+		r.percents[i].Text = fmt.Sprintf("%.0f%%", r.chart.Probabilities[i]*100)
+		r.percents[i].TextStyle.Bold = false
+	}
+
+	// Подсвечиваем три самых вероятных класса
+	for rank, class := range top {
+		if r.chart.Probabilities[class] == 0 {
+			continue
+		}
+		r.bars[class].FillColor = chartTopColors[rank]
+		r.percents[class].TextStyle.Bold = true
+	}
+
+	r.Layout(r.chart.Size())
+	for _, object := range r.objects {
+		object.Refresh()
+	}
+}
+
+func (r *probabilityChartRenderer) Objects() []fyne.CanvasObject { return r.objects }
+func (r *probabilityChartRenderer) Destroy()                     {}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	quickDrawSize     = 255 // Координаты упрощенного формата QuickDraw лежат в 0..255
+	quickDrawEpsilon  = 2.0 // Допуск упрощения линий Рамера-Дугласа-Пекера, как в QuickDraw
+	quickDrawRender   = 280 // Сторона холста, на котором штрихи рисуются перед уменьшением до 28x28
+	quickDrawRadius   = 12  // Радиус кисти при отрисовке на холсте quickDrawRender
+	quickDrawTimeText = "2006-01-02 15:04:05.00000 MST"
+)
+
+// QuickDrawing рисунок в формате NDJSON набора Google QuickDraw (одна строка файла).
+// Координаты штрихов приведены к 0..255, как в упрощенной схеме. По запросу третьим
+// массивом штриха записывается время точек в миллисекундах, как в исходной схеме QuickDraw
+type QuickDrawing struct {
+	Word        string      `json:"word"`
+	CountryCode string      `json:"countrycode"`
+	Timestamp   string      `json:"timestamp"`
+	Recognized  bool        `json:"recognized"`
+	KeyID       string      `json:"key_id"`
+	Drawing     [][][]int64 `json:"drawing"` // Штрихи: [[x...], [y...]] или [[x...], [y...], [t...]]
+}
+
+// NewQuickDrawing переводит мазки холста в рисунок QuickDraw с подписью word.
+// По умолчанию штрихи записываются по упрощенной схеме [[x...], [y...]],
+// withTime добавляет массив времени точек. Мазки ластика в формате QuickDraw
+// не представимы и пропускаются
+func NewQuickDrawing(strokes []*Stroke, word string, recognized, withTime bool) QuickDrawing {
+	q := QuickDrawing{
+		Word:        word,
+		CountryCode: "ZZ",
+		Timestamp:   time.Now().UTC().Format(quickDrawTimeText),
+		Recognized:  recognized,
+		KeyID:       strconv.FormatInt(rand.Int63n(9e15)+1e15, 10),
+		Drawing:     [][][]int64{},
+	}
+
+	// Выравниваем по левому верхнему углу и масштабируем большую сторону до 255
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, s := range strokes {
+		if s.Erase {
+			continue
+		}
+		for _, p := range s.Points {
+			minX, minY = math.Min(minX, p.X), math.Min(minY, p.Y)
+			maxX, maxY = math.Max(maxX, p.X), math.Max(maxY, p.Y)
+		}
+	}
+	size := math.Max(maxX-minX, maxY-minY)
+	if math.IsInf(size, 0) {
+		return q
+	}
+	scale := 1.0
+	if size > 0 {
+		scale = quickDrawSize / size
+	}
+
+	for _, s := range strokes {
+		if s.Erase || len(s.Points) == 0 {
+			continue
+		}
+
+		points := make([]StrokePoint, len(s.Points))
+		for i, p := range s.Points {
+			points[i] = StrokePoint{X: (p.X - minX) * scale, Y: (p.Y - minY) * scale, T: p.T}
+		}
+		points = simplifyStroke(points, quickDrawEpsilon)
+
+		stroke := [][]int64{make([]int64, len(points)), make([]int64, len(points))}
+		if withTime {
+			stroke = append(stroke, make([]int64, len(points)))
+		}
+		for i, p := range points {
+			stroke[0][i] = int64(math.Round(p.X))
+			stroke[1][i] = int64(math.Round(p.Y))
+			if withTime {
+				stroke[2][i] = p.T
+			}
+		}
+		q.Drawing = append(q.Drawing, stroke)
+	}
+
+	return q
+}
+
+// Label возвращает цифру из подписи рисунка
+func (q QuickDrawing) Label() (int, error) {
+	label, err := strconv.Atoi(q.Word)
+	if err != nil || label < 0 || label > 9 {
+		return 0, fmt.Errorf("подпись %q не является цифрой", q.Word)
+	}
+	return label, nil
+}
+
+// Strokes переводит рисунок в мазки холста side x side. Рисунок вписывается
+// в центральный квадрат с полями, как цифры MNIST в поле 28x28
+func (q QuickDrawing) Strokes(side, radius, intensity float64) ([]*Stroke, error) {
+	box := side * DigitBox / ImageSide
+	scale := box / quickDrawSize
+	offset := (side - box) / 2
+
+	strokes := make([]*Stroke, 0, len(q.Drawing))
+	for i, stroke := range q.Drawing {
+		if len(stroke) < 2 || len(stroke[0]) != len(stroke[1]) ||
+			(len(stroke) > 2 && len(stroke[2]) != len(stroke[0])) {
+			return nil, fmt.Errorf("штрих %d: массивы координат разной длины", i)
+		}
+
+		s := &Stroke{Radius: radius, Intensity: intensity}
+		for j := range stroke[0] {
+			var t int64
+			if len(stroke) > 2 {
+				t = stroke[2][j]
+			}
+			s.addPoint(float64(stroke[0][j])*scale+offset, float64(stroke[1][j])*scale+offset, t)
+		}
+		strokes = append(strokes, s)
+	}
+	return strokes, nil
+}
+
+// Render рисует штрихи на холсте высокого разрешения, уменьшает до 28x28
+// и нормализует как в MNIST
+func (q QuickDrawing) Render() ([]float64, error) {
+	strokes, err := q.Strokes(quickDrawRender, quickDrawRadius, 1)
+	if err != nil {
+		return nil, err
+	}
+	return renderStrokes(strokes), nil
+}
+
+// renderStrokes рисует мазки в координатах холста quickDrawRender и возвращает изображение 28x28
+func renderStrokes(strokes []*Stroke) []float64 {
+	d := newRenderCanvas()
+	for _, s := range strokes {
+		for i := range s.Points {
+			d.drawStroke(s, i)
+		}
+	}
+	return renderedImage(d)
+}
+
+// newRenderCanvas создает холст quickDrawRender без виджета: для отрисовки мазков нужны только данные
+func newRenderCanvas() *DrawGrid {
+	d := &DrawGrid{Cols: quickDrawRender, Rows: quickDrawRender, Data: make([][]float64, quickDrawRender)}
+	for y := range d.Data {
+		d.Data[y] = make([]float64, quickDrawRender)
+	}
+	return d
+}
+
+// renderedImage уменьшает рисунок холста quickDrawRender до 28x28 и нормализует как в MNIST
+func renderedImage(d *DrawGrid) []float64 {
+	small := ResizeArea(d.getDataForPredict(), quickDrawRender, quickDrawRender, ImageSide, ImageSide)
+	return NormalizeToMNIST(small, ImageSide, ImageSide)
+}
+
+// AugmentStrokes возвращает копию мазков со случайным поворотом, масштабом, скосом,
+// дрожанием точек и толщиной линии. Преобразование выполняется вокруг центра холста side
+func AugmentStrokes(strokes []*Stroke, side float64, rng *rand.Rand) []*Stroke {
+	angle := (rng.Float64()*2 - 1) * 15 * math.Pi / 180
+	scaleX := 0.85 + rng.Float64()*0.3
+	scaleY := 0.85 + rng.Float64()*0.3
+	shear := (rng.Float64()*2 - 1) * 0.2
+	jitter := side * 0.005
+	width := 0.8 + rng.Float64()*0.5
+
+	cos, sin := math.Cos(angle), math.Sin(angle)
+	center := side / 2
+
+	result := make([]*Stroke, len(strokes))
+	for i, s := range strokes {
+		augmented := *s
+		augmented.Radius = s.Radius * width
+		augmented.Points = make([]StrokePoint, len(s.Points))
+		for j, p := range s.Points {
+			x := (p.X - center) * scaleX
+			y := (p.Y - center) * scaleY
+			x += shear * y
+			augmented.Points[j] = StrokePoint{
+				X: center + x*cos - y*sin + rng.NormFloat64()*jitter,
+				Y: center + x*sin + y*cos + rng.NormFloat64()*jitter,
+				T: p.T,
+			}
+		}
+		result[i] = &augmented
+	}
+	return result
+}
+
+// simplifyStroke упрощает ломаную алгоритмом Рамера-Дугласа-Пекера с допуском epsilon
+func simplifyStroke(points []StrokePoint, epsilon float64) []StrokePoint {
+	if len(points) < 3 {
+		return points
+	}
+
+	first, last := points[0], points[len(points)-1]
+	index, farthest := 0, 0.0
+	for i := 1; i < len(points)-1; i++ {
+		if d := pointToSegment(points[i], first, last); d > farthest {
+			index, farthest = i, d
+		}
+	}
+
+	if farthest <= epsilon {
+		return []StrokePoint{first, last}
+	}
+
+	left := simplifyStroke(points[:index+1], epsilon)
+	right := simplifyStroke(points[index:], epsilon)
+	return append(left[:len(left)-1:len(left)-1], right...)
+}
+
+// pointToSegment расстояние от точки p до отрезка ab
+func pointToSegment(p, a, b StrokePoint) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	length := dx*dx + dy*dy
+	if length == 0 {
+		return math.Hypot(p.X-a.X, p.Y-a.Y)
+	}
+	t := math.Max(0, math.Min(1, ((p.X-a.X)*dx+(p.Y-a.Y)*dy)/length))
+	return math.Hypot(p.X-a.X-t*dx, p.Y-a.Y-t*dy)
+}
+
+// LoadQuickDraw читает рисунки из файла NDJSON
+func LoadQuickDraw(filename string) ([]QuickDrawing, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var drawings []QuickDrawing
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var q QuickDrawing
+		if err := json.Unmarshal(scanner.Bytes(), &q); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", filename, line, err)
+		}
+		drawings = append(drawings, q)
+	}
+	return drawings, scanner.Err()
+}
+
+// AppendQuickDraw дописывает рисунки в конец файла NDJSON, создавая его при необходимости
+func AppendQuickDraw(filename string, drawings ...QuickDrawing) error {
+	var data []byte
+	for _, q := range drawings {
+		line, err := json.Marshal(q)
+		if err != nil {
+			return err
+		}
+		data = append(append(data, line...), '\n')
+	}
+	return appendFile(filename, data)
+}
+
+// LoadQuickDrawDataset загружает рисунки цифр из файла NDJSON как изображения 28x28
+func LoadQuickDrawDataset(filename string) (*Dataset, error) {
+	return LoadQuickDrawAugmented(filename, 0, nil)
+}
+
+// LoadQuickDrawAugmented загружает рисунки и добавляет к каждому variants вариантов,
+// полученных аугментацией штрихов перед отрисовкой
+func LoadQuickDrawAugmented(filename string, variants int, rng *rand.Rand) (*Dataset, error) {
+	drawings, err := LoadQuickDraw(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	dataset := &Dataset{}
+	for i, q := range drawings {
+		label, err := q.Label()
+		if err != nil {
+			return nil, fmt.Errorf("%s: рисунок %d: %w", filename, i+1, err)
+		}
+		strokes, err := q.Strokes(quickDrawRender, quickDrawRadius, 1)
+		if err != nil {
+			return nil, fmt.Errorf("%s: рисунок %d: %w", filename, i+1, err)
+		}
+
+		dataset.Images = append(dataset.Images, renderStrokes(strokes))
+		dataset.Labels = append(dataset.Labels, label)
+		for v := 0; v < variants; v++ {
+			dataset.Images = append(dataset.Images, renderStrokes(AugmentStrokes(strokes, quickDrawRender, rng)))
+			dataset.Labels = append(dataset.Labels, label)
+		}
+	}
+	return dataset, nil
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDelay сколько ждать после последнего изменения файла модели перед загрузкой,
+// чтобы не читать файл, который еще записывается
+const reloadDelay = 300 * time.Millisecond
+
+// DefaultModelAlias имя, под которым доступна модель по умолчанию
+const DefaultModelAlias = "default"
+
+// modelVersion имя файла версии модели: <имя>-v<номер>
+var modelVersion = regexp.MustCompile(`^(.+)-v(\d+)$`)
+
+// Registry набор именованных моделей из каталога. Имя модели — имя файла без .json,
+// файлы вида <имя>-v<номер> считаются версиями модели <имя>. Реестр следит за каталогом
+// и загружает новые и измененные файлы на лету: сеть заменяется через Model.Set,
+// поэтому запросы, которые уже выполняются, досчитываются на прежней сети
+type Registry struct {
+	dir          string
+	file         string // Если задан, реестр содержит только этот файл каталога
+	alias        string // Модель по умолчанию, закрепляется при открытии реестра
+	batchSize    int
+	batchTimeout time.Duration
+
+	mu      sync.RWMutex
+	entries map[string]*registryEntry
+	closed  bool // После Close файлы не загружаются, чтобы не создавать новые очереди батчей
+
+	watcher *fsnotify.Watcher
+	timers  map[string]*time.Timer
+}
+
+// registryEntry модель реестра с очередью батчей
+type registryEntry struct {
+	inference
+	name    string
+	base    string // Имя модели без номера версии
+	version int
+}
+
+// RegistryModel описание модели реестра для /v1/models
+type RegistryModel struct {
+	Name    string `json:"name"`
+	Version int    `json:"version,omitempty"`
+	Default bool   `json:"default,omitempty"`
+	ModelInfo
+}
+
+// OpenRegistry загружает модели из каталога или одного файла path и начинает следить
+// за изменениями. alias — модель по умолчанию, пусто — первая по алфавиту при открытии;
+// выбор не меняется, когда в каталоге появляются новые модели. При batchSize > 1 запросы
+// к каждой модели объединяются в батчи
+func OpenRegistry(path, alias string, batchSize int, batchTimeout time.Duration) (*Registry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Registry{
+		dir:          path,
+		alias:        alias,
+		batchSize:    batchSize,
+		batchTimeout: batchTimeout,
+		entries:      make(map[string]*registryEntry),
+		timers:       make(map[string]*time.Timer),
+	}
+	if !info.IsDir() {
+		r.dir, r.file = filepath.Dir(path), filepath.Base(path)
+	}
+
+	files, err := filepath.Glob(filepath.Join(r.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if !r.watches(file) {
+			continue
+		}
+		if err := r.load(file); err != nil {
+			log.Printf("Реестр моделей: %v", err)
+		}
+	}
+	if len(r.entries) == 0 {
+		return nil, fmt.Errorf("в %s нет моделей", path)
+	}
+	if r.alias == "" {
+		r.alias = r.names()[0]
+	}
+	if _, err := r.lookup(""); err != nil {
+		return nil, err
+	}
+
+	if r.watcher, err = fsnotify.NewWatcher(); err != nil {
+		return nil, err
+	}
+	if err := r.watcher.Add(r.dir); err != nil {
+		r.watcher.Close()
+		return nil, err
+	}
+	go r.watch()
+
+	return r, nil
+}
+
+// Close прекращает слежение за каталогом и останавливает очереди батчей
+func (r *Registry) Close() {
+	r.watcher.Close()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	for _, timer := range r.timers {
+		timer.Stop()
+	}
+	for _, entry := range r.entries {
+		if entry.batcher != nil {
+			entry.batcher.Close()
+		}
+	}
+}
+
+// lookup находит модель по имени: точное имя, имя без версии (берется последняя версия)
+// или пусто и DefaultModelAlias для модели по умолчанию
+func (r *Registry) lookup(name string) (*registryEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if name == "" || name == DefaultModelAlias {
+		name = r.alias
+	}
+	if entry, ok := r.entries[name]; ok {
+		return entry, nil
+	}
+
+	var latest *registryEntry
+	for _, entry := range r.entries {
+		if entry.base == name && (latest == nil || entry.version > latest.version) {
+			latest = entry
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("модель %q не найдена", name)
+	}
+	return latest, nil
+}
+
+// names возвращает имена моделей по алфавиту. Вызывается под r.mu
+func (r *Registry) names() []string {
+	names := make([]string, 0, len(r.entries))
+	for name := range r.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Models возвращает описания всех загруженных моделей
+func (r *Registry) Models() []RegistryModel {
+	var defaultEntry *registryEntry
+	if entry, err := r.lookup(""); err == nil {
+		defaultEntry = entry
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	models := make([]RegistryModel, 0, len(r.entries))
+	for _, name := range r.names() {
+		entry := r.entries[name]
+		network := entry.model.Network()
+		if network == nil {
+			continue
+		}
+		models = append(models, RegistryModel{
+			Name:      name,
+			Version:   entry.version,
+			Default:   entry == defaultEntry,
+			ModelInfo: describeNetwork(network, entry.model.Source()),
+		})
+	}
+	return models
+}
+
+// BatchStats возвращает статистику батчей каждой модели
+func (r *Registry) BatchStats() map[string]BatchStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := make(map[string]BatchStats)
+	for name, entry := range r.entries {
+		if entry.batcher != nil {
+			stats[name] = entry.batcher.Stats()
+		}
+	}
+	return stats
+}
+
+// watches проверяет, относится ли файл к реестру
+func (r *Registry) watches(path string) bool {
+	base := filepath.Base(path)
+	if r.file != "" {
+		return base == r.file
+	}
+	return strings.HasSuffix(base, ".json")
+}
+
+// watch обрабатывает события каталога до закрытия наблюдателя
+func (r *Registry) watch() {
+	for {
+		select {
+		case event, ok := <-r.watcher.Events:
+			if !ok {
+				return
+			}
+			if r.watches(event.Name) && !event.Has(fsnotify.Chmod) {
+				r.scheduleReload(event.Name)
+			}
+		case err, ok := <-r.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("Реестр моделей: %v", err)
+		}
+	}
+}
+
+// scheduleReload откладывает перезагрузку файла до окончания серии изменений
+func (r *Registry) scheduleReload(path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return
+	}
+	if timer, ok := r.timers[path]; ok {
+		timer.Reset(reloadDelay)
+		return
+	}
+	r.timers[path] = time.AfterFunc(reloadDelay, func() {
+		r.mu.Lock()
+		delete(r.timers, path)
+		closed := r.closed
+		r.mu.Unlock()
+		if closed {
+			return
+		}
+
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			r.remove(path)
+			return
+		}
+		if err := r.load(path); err != nil {
+			log.Printf("Реестр моделей: %v, продолжает работать прежняя версия", err)
+		}
+	})
+}
+
+// load загружает файл модели и публикует его в реестре, заменяя прежнюю сеть с тем же именем.
+// LoadNetwork проверяет размеры слоев, поэтому поврежденный файл не заменит рабочую сеть
+func (r *Registry) load(path string) error {
+	network, err := LoadNetwork(path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), ".json")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	if entry, ok := r.entries[name]; ok {
+		entry.model.Set(network, path)
+		log.Printf("Реестр моделей: модель %s обновлена из %s", name, path)
+		return nil
+	}
+
+	entry := &registryEntry{name: name, base: name}
+	if match := modelVersion.FindStringSubmatch(name); match != nil {
+		entry.base = match[1]
+		entry.version, _ = strconv.Atoi(match[2])
+	}
+	entry.model = NewModel(network, path)
+	if r.batchSize > 1 {
+		entry.batcher = NewBatcher(entry.model, r.batchSize, r.batchTimeout)
+	}
+	r.entries[name] = entry
+	log.Printf("Реестр моделей: загружена модель %s из %s", name, path)
+	return nil
+}
+
+// remove убирает из реестра модель удаленного файла
+func (r *Registry) remove(path string) {
+	name := strings.TrimSuffix(filepath.Base(path), ".json")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.entries[name]
+	if !ok || r.closed {
+		return
+	}
+	if r.onlyDefault(name) {
+		log.Printf("Реестр моделей: файл модели по умолчанию %s удален, модель остается загруженной", path)
+		return
+	}
+	delete(r.entries, name)
+	if entry.batcher != nil {
+		entry.batcher.Close()
+	}
+	log.Printf("Реестр моделей: модель %s удалена", name)
+}
+
+// onlyDefault сообщает, что кроме модели name псевдониму по умолчанию не соответствует
+// ни одна модель, и без нее перестали бы работать запросы без имени. Вызывается под r.mu
+func (r *Registry) onlyDefault(name string) bool {
+	if entry := r.entries[name]; name != r.alias && entry.base != r.alias {
+		return false
+	}
+	for other, entry := range r.entries {
+		if other != name && (other == r.alias || entry.base == r.alias) {
+			return false
+		}
+	}
+	return true
+}
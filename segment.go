@@ -0,0 +1,296 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	inkThreshold   = 0.1  // Минимальное значение пикселя, который считается чернилами
+	minSegmentInk  = 4    // Компоненты меньшего размера считаются шумом
+	mergeOverlap   = 0.5  // Доля ширины, при перекрытии на которую компоненты объединяются
+	maxDigitAspect = 1.0  // Более широкие сегменты считаются слипшимися цифрами
+	splitSearch    = 0.25 // Разрез ищется в средней части сегмента, отступая эту долю с краев
+	splitCentering = 2    // Штраф за удаление разреза от середины сегмента
+)
+
+// Segment фрагмент изображения с одной цифрой
+type Segment struct {
+	MinX, MinY, MaxX, MaxY int       // Ограничивающая рамка в исходном изображении
+	Pixels                 []float64 // Изображение рамки, чужие чернила удалены
+}
+
+// Width ширина рамки сегмента
+func (s Segment) Width() int { return s.MaxX - s.MinX + 1 }
+
+// Height высота рамки сегмента
+func (s Segment) Height() int { return s.MaxY - s.MinY + 1 }
+
+// DigitResult распознанная цифра многозначного числа
+type DigitResult struct {
+	Segment       Segment
+	Input         []float64 // Сегмент, нормализованный как в MNIST
+	Digit         int
+	Confidence    float64
+	Probabilities []float64
+}
+
+// SegmentDigits разбивает изображение w x h с написанным числом на цифры слева направо.
+// Цифры выделяются как связные компоненты; компоненты, лежащие друг над другом
+// (например, отдельно написанная крышка пятерки), объединяются, а слишком широкие
+// сегменты разрезаются по минимуму вертикальной проекции
+func SegmentDigits(pix []float64, w, h int) []Segment {
+	labels, count := connectedComponents(pix, w, h)
+
+	// Рамки компонент
+	groups := make([]*componentGroup, 0, count)
+	for i := 0; i < count; i++ {
+		groups = append(groups, &componentGroup{minX: w, minY: h, maxX: -1, maxY: -1, labels: []int{i + 1}})
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if l := labels[y*w+x]; l > 0 {
+				groups[l-1].add(x, y)
+			}
+		}
+	}
+
+	// Отбрасываем шум
+	kept := groups[:0]
+	for _, g := range groups {
+		if g.ink >= minSegmentInk {
+			kept = append(kept, g)
+		}
+	}
+	groups = mergeOverlapping(kept)
+
+	var segments []Segment
+	for _, g := range groups {
+		segments = append(segments, splitWide(g.segment(pix, labels, w))...)
+	}
+
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].MinX < segments[j].MinX
+	})
+	return segments
+}
+
+// RecognizeNumber распознает многозначное число на изображении w x h
+func RecognizeNumber(network *Network, pix []float64, w, h int) []DigitResult {
+	segments := SegmentDigits(pix, w, h)
+	results := make([]DigitResult, len(segments))
+
+	for i, s := range segments {
+		input := NormalizeToMNIST(s.Pixels, s.Width(), s.Height())
+		output := network.Predict(input)
+		digit := ArgMax(output)
+		results[i] = DigitResult{
+			Segment:       s,
+			Input:         input,
+			Digit:         digit,
+			Confidence:    output[digit],
+			Probabilities: output,
+		}
+	}
+	return results
+}
+
+// NumberString собирает распознанные цифры в строку
+func NumberString(results []DigitResult) string {
+	var b strings.Builder
+	for _, r := range results {
+		b.WriteByte(byte('0' + r.Digit))
+	}
+	return b.String()
+}
+
+// componentGroup одна или несколько объединенных связных компонент
+type componentGroup struct {
+	minX, minY, maxX, maxY int
+	ink                    int // Количество пикселей с чернилами
+	labels                 []int
+}
+
+func (g *componentGroup) add(x, y int) {
+	g.minX = min(g.minX, x)
+	g.minY = min(g.minY, y)
+	g.maxX = max(g.maxX, x)
+	g.maxY = max(g.maxY, y)
+	g.ink++
+}
+
+// overlap доля ширины более узкой из групп, на которую они перекрываются по горизонтали
+func (g *componentGroup) overlap(other *componentGroup) float64 {
+	common := min(g.maxX, other.maxX) - max(g.minX, other.minX) + 1
+	narrow := min(g.maxX-g.minX, other.maxX-other.minX) + 1
+	return float64(common) / float64(narrow)
+}
+
+// segment вырезает рамку группы, оставляя только пиксели ее компонент
+func (g *componentGroup) segment(pix []float64, labels []int, w int) Segment {
+	s := Segment{MinX: g.minX, MinY: g.minY, MaxX: g.maxX, MaxY: g.maxY}
+	sw := s.Width()
+	s.Pixels = make([]float64, sw*s.Height())
+
+	for y := g.minY; y <= g.maxY; y++ {
+		for x := g.minX; x <= g.maxX; x++ {
+			for _, l := range g.labels {
+				if labels[y*w+x] == l {
+					s.Pixels[(y-g.minY)*sw+x-g.minX] = pix[y*w+x]
+					break
+				}
+			}
+		}
+	}
+	return s
+}
+
+// mergeOverlapping объединяет группы, сильно перекрывающиеся по горизонтали
+func mergeOverlapping(groups []*componentGroup) []*componentGroup {
+	sort.Slice(groups, func(i, j int) bool { return groups[i].minX < groups[j].minX })
+
+	var merged []*componentGroup
+	for _, g := range groups {
+		if n := len(merged); n > 0 && merged[n-1].overlap(g) >= mergeOverlap {
+			last := merged[n-1]
+			last.minX = min(last.minX, g.minX)
+			last.minY = min(last.minY, g.minY)
+			last.maxX = max(last.maxX, g.maxX)
+			last.maxY = max(last.maxY, g.maxY)
+			last.ink += g.ink
+			last.labels = append(last.labels, g.labels...)
+			continue
+		}
+		merged = append(merged, g)
+	}
+	return merged
+}
+
+// splitWide рекурсивно разрезает слишком широкий сегмент по колонке его средней части,
+// где меньше всего чернил. Количество чернил умножается на число пересекаемых штрихов
+// и штраф за удаление от середины, чтобы разрез не прошел через круглую цифру вроде 0 или 8.
+// Части, в которых после разреза почти не осталось чернил, отбрасываются как шум
+func splitWide(s Segment) []Segment {
+	w, h := s.Width(), s.Height()
+	if float64(w) <= maxDigitAspect*float64(h) {
+		return []Segment{s}
+	}
+
+	// Вертикальная проекция и количество штрихов в каждой колонке
+	profile := make([]float64, w)
+	runs := make([]int, w)
+	for x := 0; x < w; x++ {
+		inside := false
+		for y := 0; y < h; y++ {
+			value := s.Pixels[y*w+x]
+			profile[x] += value
+			if value > inkThreshold && !inside {
+				runs[x]++
+			}
+			inside = value > inkThreshold
+		}
+	}
+
+	cost := func(x int) float64 {
+		dist := math.Abs(float64(x) - float64(w-1)/2)
+		return profile[x] * float64(runs[x]) * (1 + splitCentering*dist/float64(w))
+	}
+
+	cut := -1
+	for x := int(float64(w) * splitSearch); x < w-int(float64(w)*splitSearch); x++ {
+		if cut < 0 || cost(x) < cost(cut) {
+			cut = x
+		}
+	}
+	if cut <= 0 || cut >= w-1 {
+		return []Segment{s}
+	}
+
+	var parts []Segment
+	for _, part := range []Segment{s.crop(0, cut), s.crop(cut, w)} {
+		if part.ink() >= minSegmentInk {
+			parts = append(parts, splitWide(part)...)
+		}
+	}
+	if len(parts) == 0 {
+		return []Segment{s}
+	}
+	return parts
+}
+
+// ink количество пикселей сегмента с чернилами
+func (s Segment) ink() int {
+	count := 0
+	for _, value := range s.Pixels {
+		if value > inkThreshold {
+			count++
+		}
+	}
+	return count
+}
+
+// crop вырезает колонки [from, to) сегмента и подрезает рамку по чернилам
+func (s Segment) crop(from, to int) Segment {
+	w, h := s.Width(), s.Height()
+	cw := to - from
+	pixels := make([]float64, cw*h)
+	for y := 0; y < h; y++ {
+		copy(pixels[y*cw:(y+1)*cw], s.Pixels[y*w+from:y*w+to])
+	}
+
+	minX, minY, maxX, maxY, ok := inkBounds(pixels, cw, h, inkThreshold)
+	if !ok {
+		return Segment{MinX: s.MinX + from, MinY: s.MinY, MaxX: s.MinX + to - 1, MaxY: s.MaxY, Pixels: pixels}
+	}
+
+	result := Segment{
+		MinX: s.MinX + from + minX,
+		MinY: s.MinY + minY,
+		MaxX: s.MinX + from + maxX,
+		MaxY: s.MinY + maxY,
+	}
+	rw := maxX - minX + 1
+	result.Pixels = make([]float64, rw*(maxY-minY+1))
+	for y := minY; y <= maxY; y++ {
+		copy(result.Pixels[(y-minY)*rw:(y-minY+1)*rw], pixels[y*cw+minX:y*cw+maxX+1])
+	}
+	return result
+}
+
+// connectedComponents размечает 8-связные области чернил номерами 1..count
+func connectedComponents(pix []float64, w, h int) ([]int, int) {
+	labels := make([]int, w*h)
+	count := 0
+	var stack []int
+
+	for start := range pix {
+		if pix[start] <= inkThreshold || labels[start] != 0 {
+			continue
+		}
+
+		count++
+		labels[start] = count
+		stack = append(stack[:0], start)
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			x, y := i%w, i/w
+
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					nx, ny := x+dx, y+dy
+					if nx < 0 || nx >= w || ny < 0 || ny >= h {
+						continue
+					}
+					j := ny*w + nx
+					if pix[j] > inkThreshold && labels[j] == 0 {
+						labels[j] = count
+						stack = append(stack, j)
+					}
+				}
+			}
+		}
+	}
+	return labels, count
+}
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+const (
+	maxRequestSize    = 10 << 20         // Наибольший размер тела запроса предсказания
+	readHeaderTimeout = 5 * time.Second  // Сколько ждать заголовки запроса
+	readTimeout       = 30 * time.Second // Сколько ждать весь запрос вместе с телом
+	idleTimeout       = 2 * time.Minute  // Сколько держать открытым простаивающее соединение
+	shutdownTimeout   = 10 * time.Second // Сколько ждать завершения запросов при остановке
+)
+
+// errNoModel ошибка запроса к серверу без загруженной модели
+var errNoModel = errors.New("модель не загружена")
+
+// Server HTTP-сервер предсказаний. Использует только Network.Predict и PredictBatch,
+// поэтому запросы обрабатываются параллельно, а модели реестра заменяются на лету.
+// Модель выбирается параметром ?model=, без него используется модель по умолчанию
+type Server struct {
+	registry *Registry
+	metrics  *Metrics
+	mux      *http.ServeMux
+	handler  http.Handler
+}
+
+// inference модель и необязательная очередь батчей, общие для HTTP- и gRPC-сервера
+type inference struct {
+	model   *Model
+	batcher *Batcher // Может быть nil, тогда каждый запрос выполняется отдельно
+}
+
+// NewServer создает сервер для моделей реестра, запросы учитываются в metrics
+func NewServer(registry *Registry, metrics *Metrics) *Server {
+	s := &Server{registry: registry, metrics: metrics, mux: http.NewServeMux()}
+	s.mux.HandleFunc("POST /v1/predict", s.handlePredict)
+	s.mux.HandleFunc("GET /v1/model", s.handleModel)
+	s.mux.HandleFunc("GET /v1/models", s.handleModels)
+	s.mux.HandleFunc("GET /v1/batching", s.handleBatching)
+	s.mux.HandleFunc("GET /v1/ws", s.handleWebSocket)
+	s.mux.HandleFunc("GET /healthz", s.handleHealth)
+	s.mux.Handle("GET /metrics", metrics.Handler())
+	s.mux.HandleFunc("GET /{$}", s.handlePage)
+	s.handler = metrics.Middleware(s.mux)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// PredictRequest тело запроса в формате JSON: либо пиксели 28x28 (0..1, белая цифра на черном фоне),
+// либо изображение PNG/JPEG в base64, которое нормализуется как в MNIST.
+// Вместо объекта можно передать просто массив из 784 чисел
+type PredictRequest struct {
+	Pixels []float64 `json:"pixels,omitempty"`
+	Image  string    `json:"image,omitempty"`
+}
+
+// PredictResponse результат предсказания
+type PredictResponse struct {
+	Prediction    int       `json:"prediction"`
+	Confidence    float64   `json:"confidence"`
+	Probabilities []float64 `json:"probabilities"`
+	LatencyMs     float64   `json:"latency_ms"`
+	Model         string    `json:"model"`
+}
+
+// ModelInfo описание загруженной модели
+type ModelInfo struct {
+	Source       string  `json:"source"`
+	Architecture []int   `json:"architecture"`
+	Parameters   int     `json:"parameters"`
+	Transforms   string  `json:"transforms,omitempty"`
+	TestAccuracy float64 `json:"test_accuracy,omitempty"`
+}
+
+// errorResponse тело ответа с ошибкой
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// handlePredict принимает JSON (массив пикселей или base64 PNG) либо multipart/form-data с файлом image
+func (s *Server) handlePredict(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	entry, err := s.registry.lookup(r.URL.Query().Get("model"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestSize)
+	input, status, err := readPredictInput(r)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+
+	output, source, err := entry.predict(r.Context(), input)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	s.metrics.observePrediction(entry.name, output)
+
+	prediction := ArgMax(output)
+	writeJSON(w, http.StatusOK, PredictResponse{
+		Prediction:    prediction,
+		Confidence:    output[prediction],
+		Probabilities: output,
+		LatencyMs:     float64(time.Since(start).Microseconds()) / 1000,
+		Model:         source,
+	})
+}
+
+// predict вычисляет вероятности через очередь батчей или напрямую
+func (in inference) predict(ctx context.Context, input []float64) ([]float64, string, error) {
+	if in.batcher != nil {
+		return in.batcher.Predict(ctx, input)
+	}
+
+	network, source := in.model.Network(), in.model.Source()
+	if network == nil {
+		return nil, "", errNoModel
+	}
+	return network.Predict(input), source, nil
+}
+
+// readPredictInput извлекает изображение 28x28 из запроса, при ошибке возвращает HTTP-статус
+func readPredictInput(r *http.Request) ([]float64, int, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, http.StatusUnsupportedMediaType, fmt.Errorf("неверный Content-Type: %w", err)
+	}
+
+	switch mediaType {
+	case "application/json":
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, readErrorStatus(err), err
+		}
+		return parsePredictJSON(body)
+
+	case "multipart/form-data":
+		file, _, err := r.FormFile("image")
+		if err != nil {
+			return nil, readErrorStatus(err), fmt.Errorf("ожидается файл в поле image: %w", err)
+		}
+		defer file.Close()
+
+		input, err := DecodeImage(file)
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("не удалось прочитать изображение: %w", err)
+		}
+		return input, http.StatusOK, nil
+	}
+
+	return nil, http.StatusUnsupportedMediaType,
+		fmt.Errorf("неподдерживаемый Content-Type %q, ожидается application/json или multipart/form-data", mediaType)
+}
+
+// readErrorStatus статус ответа для ошибки чтения тела запроса
+func readErrorStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// parsePredictJSON разбирает массив пикселей или объект PredictRequest
+func parsePredictJSON(body []byte) ([]float64, int, error) {
+	var request PredictRequest
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &request.Pixels); err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("неверный массив пикселей: %w", err)
+		}
+	} else if err := json.Unmarshal(body, &request); err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("неверный JSON: %w", err)
+	}
+
+	switch {
+	case request.Image != "":
+		data, err := base64.StdEncoding.DecodeString(request.Image)
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("неверный base64: %w", err)
+		}
+		input, err := DecodeImage(bytes.NewReader(data))
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("не удалось прочитать изображение: %w", err)
+		}
+		return input, http.StatusOK, nil
+
+	case len(request.Pixels) != ImagePixels:
+		return nil, http.StatusBadRequest, fmt.Errorf("ожидается %d пикселей, получено %d", ImagePixels, len(request.Pixels))
+	}
+
+	return request.Pixels, http.StatusOK, nil
+}
+
+func (s *Server) handleModel(w http.ResponseWriter, r *http.Request) {
+	entry, err := s.registry.lookup(r.URL.Query().Get("model"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	network := entry.model.Network()
+	if network == nil {
+		writeError(w, http.StatusServiceUnavailable, errNoModel)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, describeNetwork(network, entry.model.Source()))
+}
+
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.registry.Models())
+}
+
+// handleBatching возвращает статистику батчей по именам моделей
+func (s *Server) handleBatching(w http.ResponseWriter, r *http.Request) {
+	if s.registry.batchSize <= 1 {
+		writeError(w, http.StatusNotFound, errors.New("объединение запросов в батчи отключено"))
+		return
+	}
+	writeJSON(w, http.StatusOK, s.registry.BatchStats())
+}
+
+// describeNetwork собирает описание сети для /v1/model
+func describeNetwork(network *Network, source string) ModelInfo {
+	parameters := 0
+	for _, layer := range network.Layers {
+		parameters += len(layer.Biases)
+		for _, weights := range layer.Weights {
+			parameters += len(weights)
+		}
+	}
+
+	return ModelInfo{
+		Source:       source,
+		Architecture: network.Architecture(),
+		Parameters:   parameters,
+		Transforms:   network.Transforms.String(),
+		TestAccuracy: network.TestAccuracy,
+	}
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if _, err := s.registry.lookup(""); err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "no model"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// pageHost адрес для ссылки на страницу рисования: пустой хост заменяется на localhost
+func pageHost(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || (host != "" && host != "0.0.0.0" && host != "::") {
+		return addr
+	}
+	return net.JoinHostPort("localhost", port)
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Printf("Ошибка записи ответа: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// runServe запускает HTTP- и gRPC-серверы предсказаний с общим реестром моделей
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	modelPath := flags.String("model", "mnist_model.json", "файл модели, перезагружается при изменении")
+	modelsDir := flags.String("models", "", "каталог моделей *.json вместо одного файла -model")
+	alias := flags.String("default", "", "модель по умолчанию (имя файла без .json); пусто — первая по алфавиту при запуске")
+	addr := flags.String("addr", ":8080", "адрес HTTP-сервера (пусто — без HTTP)")
+	grpcAddr := flags.String("grpc-addr", "", "адрес gRPC-сервера (пусто — без gRPC)")
+	batchSize := flags.Int("batch-size", 32, "наибольший размер батча (1 — без объединения запросов)")
+	batchTimeout := flags.Duration("batch-timeout", 2*time.Millisecond, "сколько ждать запросы для неполного батча")
+	lowConfidence := flags.Float64("low-confidence", 0.5, "порог уверенности для метрики неуверенных предсказаний")
+	logJSON := flags.Bool("log-json", false, "журнал в формате JSON вместо текста")
+	flags.Parse(args)
+
+	var handler slog.Handler = slog.NewTextHandler(os.Stderr, nil)
+	if *logJSON {
+		handler = slog.NewJSONHandler(os.Stderr, nil)
+	}
+	slog.SetDefault(slog.New(handler))
+
+	if *addr == "" && *grpcAddr == "" {
+		return errors.New("необходимо указать -addr или -grpc-addr")
+	}
+
+	path := *modelPath
+	if *modelsDir != "" {
+		path = *modelsDir
+	}
+	registry, err := OpenRegistry(path, *alias, *batchSize, *batchTimeout)
+	if err != nil {
+		return err
+	}
+	defer registry.Close()
+	metrics := NewMetrics(registry, *lowConfidence)
+
+	// По SIGINT и SIGTERM серверы дожидаются текущих запросов, затем реестр
+	// закрывает очереди батчей и наблюдение за файлами моделей
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errs := make(chan error, 2)
+	var grpcServer *grpc.Server
+	if *grpcAddr != "" {
+		listener, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			return err
+		}
+		grpcServer = NewGRPCServer(registry, metrics)
+		log.Printf("gRPC-сервер предсказаний на %s, модели из %s", *grpcAddr, path)
+		go func() { errs <- grpcServer.Serve(listener) }()
+	}
+	var httpServer *http.Server
+	if *addr != "" {
+		httpServer = &http.Server{
+			Addr:              *addr,
+			Handler:           NewServer(registry, metrics),
+			ReadHeaderTimeout: readHeaderTimeout,
+			ReadTimeout:       readTimeout,
+			IdleTimeout:       idleTimeout,
+		}
+		log.Printf("Сервер предсказаний на %s, модели из %s; страница рисования http://%s/", *addr, path, pageHost(*addr))
+		go func() { errs <- httpServer.ListenAndServe() }()
+	}
+
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+		log.Printf("Остановка сервера")
+	}
+	shutdownServers(httpServer, grpcServer)
+	return err
+}
+
+// shutdownServers останавливает серверы, давая текущим запросам shutdownTimeout на завершение
+func shutdownServers(httpServer *http.Server, grpcServer *grpc.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if httpServer != nil {
+		if err := httpServer.Shutdown(ctx); err != nil {
+			httpServer.Close()
+		}
+	}
+	if grpcServer != nil {
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			grpcServer.Stop()
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"image"
+	"math"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+// StrokePoint точка мазка в дробных координатах клеток
+type StrokePoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	T int64   `json:"t,omitempty"` // Время от начала рисунка в миллисекундах
+}
+
+// Stroke мазок кисти от нажатия до отпускания кнопки мыши
+type Stroke struct {
+	Points    []StrokePoint `json:"points"`
+	Radius    float64       `json:"radius"`
+	Intensity float64       `json:"intensity"`
+	Erase     bool          `json:"erase,omitempty"`
+}
+
+func (s *Stroke) addPoint(x, y float64, t int64) {
+	s.Points = append(s.Points, StrokePoint{X: x, Y: y, T: t})
+}
+
+// addPoint добавляет в текущий мазок точку под курсором с отметкой времени
+func (d *DrawGrid) addPoint(pos fyne.Position) {
+	now := time.Now()
+	if d.started.IsZero() {
+		d.started = now
+	}
+	x, y := d.pointToCell(pos)
+	d.current.addPoint(x, y, now.Sub(d.started).Milliseconds())
+}
+
+// Strokes возвращает мазки текущего рисунка
+func (d *DrawGrid) Strokes() []*Stroke {
+	return d.strokes
+}
+
+// SetStrokes заменяет рисунок мазками strokes на пустом холсте
+func (d *DrawGrid) SetStrokes(strokes []*Stroke) {
+	d.base = nil
+	d.strokes = strokes
+	d.undone = nil
+	d.started = time.Time{}
+	d.redraw()
+}
+
+// stamp накладывает мягкий отпечаток кисти с центром в точке (cx, cy).
+// Интенсивность спадает от центра к краю, повторные мазки накапливаются до 1,
+// ластик так же плавно уменьшает значения до 0
+func (d *DrawGrid) stamp(cx, cy float64, s *Stroke) {
+	radius := math.Max(s.Radius, 0.5)
+	inner := radius * 0.4
+
+	minX := max(0, int(math.Floor(cx-radius)))
+	maxX := min(d.Cols-1, int(math.Ceil(cx+radius)))
+	minY := max(0, int(math.Floor(cy-radius)))
+	maxY := min(d.Rows-1, int(math.Ceil(cy+radius)))
+	d.touch(image.Rectangle{Min: image.Pt(minX, minY), Max: image.Pt(maxX+1, maxY+1)})
+
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			dist := math.Hypot(float64(x)+0.5-cx, float64(y)+0.5-cy)
+			if dist >= radius {
+				continue
+			}
+
+			weight := 1.0
+			if dist > inner {
+				weight = (radius - dist) / (radius - inner)
+			}
+
+			value := s.Intensity * weight
+			if s.Erase {
+				d.Data[y][x] *= 1 - value
+			} else {
+				d.Data[y][x] = 1 - (1-d.Data[y][x])*(1-value)
+			}
+		}
+	}
+}
+
+// drawStroke рисует точку мазка с индексом i: первую точку отпечатком,
+// остальные линией от предыдущей, чтобы быстрые движения не оставляли разрывов
+func (d *DrawGrid) drawStroke(s *Stroke, i int) {
+	to := s.Points[i]
+	if i == 0 {
+		d.stamp(to.X, to.Y, s)
+		return
+	}
+
+	from := s.Points[i-1]
+	dist := math.Hypot(to.X-from.X, to.Y-from.Y)
+	step := math.Max(s.Radius*0.5, 0.25)
+	steps := int(math.Ceil(dist / step))
+	for j := 1; j <= steps; j++ {
+		t := float64(j) / float64(steps)
+		d.stamp(from.X+(to.X-from.X)*t, from.Y+(to.Y-from.Y)*t, s)
+	}
+}
+
+// redraw перерисовывает рисунок по истории мазков
+func (d *DrawGrid) redraw() {
+	d.resetData()
+	for _, s := range d.strokes {
+		for i := range s.Points {
+			d.drawStroke(s, i)
+		}
+	}
+	d.changed()
+	d.Refresh()
+}
+
+// Undo отменяет последний мазок
+func (d *DrawGrid) Undo() {
+	if d.replaying || len(d.strokes) == 0 {
+		return
+	}
+
+	last := d.strokes[len(d.strokes)-1]
+	d.strokes = d.strokes[:len(d.strokes)-1]
+	d.undone = append(d.undone, last)
+	d.redraw()
+}
+
+// Redo возвращает последний отмененный мазок
+func (d *DrawGrid) Redo() {
+	if d.replaying || len(d.undone) == 0 {
+		return
+	}
+
+	last := d.undone[len(d.undone)-1]
+	d.undone = d.undone[:len(d.undone)-1]
+	d.strokes = append(d.strokes, last)
+	d.redraw()
+}
+
+// maxReplayPause самая долгая пауза при воспроизведении, даже если рисующий задумался
+const maxReplayPause = time.Second
+
+// Replay заново проигрывает рисунок по мазкам. Точки с отметками времени воспроизводятся
+// в исходном темпе, для остальных пауза между точками равна delay
+func (d *DrawGrid) Replay(delay time.Duration) {
+	if d.replaying || len(d.strokes) == 0 {
+		return
+	}
+
+	d.replaying = true
+	d.replayStart()
+	strokes := d.strokes
+
+	go func() {
+		var prev *StrokePoint
+		for _, s := range strokes {
+			for i := range s.Points {
+				point := &s.Points[i]
+				if prev != nil && point.T > prev.T {
+					time.Sleep(min(time.Duration(point.T-prev.T)*time.Millisecond, maxReplayPause))
+				} else if prev != nil {
+					time.Sleep(delay)
+				}
+				prev = point
+
+				fyne.DoAndWait(func() { d.replayPoint(s, i) })
+			}
+		}
+
+		fyne.Do(d.replayDone)
+	}()
+}
+
+// replayStart очищает холст перед воспроизведением
+func (d *DrawGrid) replayStart() {
+	d.resetData()
+	d.Overlay = nil
+	d.flushPending()
+	d.Refresh()
+}
+
+// replayPoint показывает очередную точку воспроизводимого мазка.
+// OnChanged не вызывается, пока рисунок не воспроизведен целиком
+func (d *DrawGrid) replayPoint(s *Stroke, i int) {
+	d.drawStroke(s, i)
+	d.flushPending()
+	d.Refresh()
+}
+
+// replayDone заканчивает воспроизведение: за это время рисунок изменился целиком
+func (d *DrawGrid) replayDone() {
+	d.replaying = false
+	d.touch(d.bounds())
+	d.changed()
+	d.Refresh()
+}
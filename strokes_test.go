@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/test"
+)
+
+// testStrokes два мазка поперек холста 28x28
+func testStrokes() []*Stroke {
+	first := &Stroke{Radius: 1.2, Intensity: 0.6}
+	second := &Stroke{Radius: 1.2, Intensity: 0.6}
+	for i := 0; i < 5; i++ {
+		first.addPoint(14, 4+float64(i)*5, 0)
+		second.addPoint(4+float64(i)*5, 14, 0)
+	}
+	return []*Stroke{first, second}
+}
+
+func TestReplayMarksCellsDirty(t *testing.T) {
+	test.NewTempApp(t)
+
+	// Клетки меньше rasterCellSize: холст рисуется растровым отрисовщиком,
+	// который забирает измененные клетки только при отрисовке кадра
+	d := NewDrawGridSize(GridSize, GridSize, 2)
+	test.WidgetRenderer(d)
+	d.SetStrokes(testStrokes())
+	d.takeDirty()
+
+	d.replayStart()
+	if d.takeDirty() != d.bounds() {
+		t.Error("после начала воспроизведения холст не отмечен для перерисовки целиком")
+	}
+	for n, s := range d.strokes {
+		for i := range s.Points {
+			d.replayPoint(s, i)
+			if d.takeDirty().Empty() {
+				t.Errorf("мазок %d, точка %d: нет клеток для перерисовки", n, i)
+			}
+		}
+	}
+}
+
+func TestReplay(t *testing.T) {
+	test.NewTempApp(t)
+
+	d := NewDrawGridSize(GridSize, GridSize, 2)
+	test.WidgetRenderer(d)
+	d.SetStrokes(testStrokes())
+	want := d.getDataForPredict()
+	d.takeDirty()
+
+	done := make(chan struct{})
+	d.OnChanged = func() { close(done) }
+	d.Replay(0)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("воспроизведение не закончилось")
+	}
+
+	if d.takeDirty() != d.bounds() {
+		t.Error("после воспроизведения холст не отмечен для перерисовки целиком")
+	}
+	if d.LastChange != d.bounds() {
+		t.Errorf("LastChange = %v, ожидается весь холст", d.LastChange)
+	}
+	for i, value := range d.getDataForPredict() {
+		if value != want[i] {
+			t.Fatalf("клетка %d: %g после воспроизведения, ожидается %g", i, value, want[i])
+		}
+	}
+}
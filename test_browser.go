@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const anyClass = "любая"
+
+// testPrediction предсказание сети для одного тестового изображения
+type testPrediction struct {
+	index         int
+	label         int
+	predicted     int
+	confidence    float64
+	probabilities []float64
+}
+
+// testBrowser вкладка просмотра тестовой выборки с фильтрами
+type testBrowser struct {
+	p       *Predictor
+	test    *Dataset
+	results []testPrediction
+	view    []int // Индексы results после фильтрации и сортировки
+	current int
+
+	grid        *DrawGrid
+	chart       *ProbabilityChart
+	info        *widget.Label
+	position    *widget.Label
+	errorsCheck *widget.Check
+	sortCheck   *widget.Check
+	trueSelect  *widget.Select
+	predSelect  *widget.Select
+	computeBtn  *widget.Button
+}
+
+// buildTestBrowserTab создает вкладку просмотра тестовой выборки
+func (p *Predictor) buildTestBrowserTab() fyne.CanvasObject {
+	b := &testBrowser{p: p}
+
+	b.grid = NewDrawGrid()
+	b.grid.ReadOnly = true
+	b.chart = NewProbabilityChart()
+	b.info = widget.NewLabel("Нажмите \"Вычислить предсказания\", чтобы просмотреть тестовую выборку")
+	b.position = widget.NewLabel("")
+
+	classes := []string{anyClass}
+	for i := 0; i < 10; i++ {
+		classes = append(classes, strconv.Itoa(i))
+	}
+	b.trueSelect = widget.NewSelect(classes, func(string) { b.applyFilter() })
+	b.trueSelect.SetSelected(anyClass)
+	b.predSelect = widget.NewSelect(classes, func(string) { b.applyFilter() })
+	b.predSelect.SetSelected(anyClass)
+
+	b.errorsCheck = widget.NewCheck("Только ошибки", func(bool) { b.applyFilter() })
+	b.sortCheck = widget.NewCheck("Сначала наименее уверенные", func(bool) { b.applyFilter() })
+
+	b.computeBtn = widget.NewButton("Вычислить предсказания", b.compute)
+	prevBtn := widget.NewButton("< Назад", func() { b.show(b.current - 1) })
+	nextBtn := widget.NewButton("Вперед >", func() { b.show(b.current + 1) })
+
+	return container.NewHBox(
+		container.NewVBox(
+			b.grid,
+			container.NewHBox(prevBtn, nextBtn),
+			b.position,
+		),
+		container.NewVBox(
+			b.computeBtn,
+			widget.NewForm(
+				widget.NewFormItem("Истинная цифра", b.trueSelect),
+				widget.NewFormItem("Предсказанная цифра", b.predSelect),
+			),
+			b.errorsCheck,
+			b.sortCheck,
+			b.info,
+			b.chart,
+		),
+	)
+}
+
+// compute в фоне вычисляет предсказания текущей модели для всей тестовой выборки
+func (b *testBrowser) compute() {
+	network := b.p.model.Network()
+	if network == nil {
+		dialog.ShowError(fmt.Errorf("модель не загружена"), b.p.window)
+		return
+	}
+
+	b.computeBtn.Disable()
+	b.info.SetText("Вычисление предсказаний...")
+
+	go func() {
+		_, test, err := b.p.loadMNIST()
+		if err != nil {
+			fyne.Do(func() {
+				dialog.ShowError(err, b.p.window)
+				b.computeBtn.Enable()
+			})
+			return
+		}
+
+		results := make([]testPrediction, test.Len())
+		for i, image := range test.Images {
+			output := network.Predict(image)
+			predicted := ArgMax(output)
+			results[i] = testPrediction{
+				index:         i,
+				label:         test.Labels[i],
+				predicted:     predicted,
+				confidence:    output[predicted],
+				probabilities: output,
+			}
+		}
+
+		fyne.Do(func() {
+			b.test = test
+			b.results = results
+			b.computeBtn.Enable()
+			b.applyFilter()
+		})
+	}()
+}
+
+// applyFilter пересобирает список просматриваемых изображений
+func (b *testBrowser) applyFilter() {
+	if b.results == nil {
+		return
+	}
+
+	trueClass, _ := strconv.Atoi(b.trueSelect.Selected)
+	predClass, _ := strconv.Atoi(b.predSelect.Selected)
+	anyTrue := b.trueSelect.Selected == anyClass
+	anyPred := b.predSelect.Selected == anyClass
+
+	b.view = b.view[:0]
+	errors := 0
+	for i, r := range b.results {
+		if r.label != r.predicted {
+			errors++
+		}
+		if b.errorsCheck.Checked && r.label == r.predicted {
+			continue
+		}
+		if !anyTrue && r.label != trueClass {
+			continue
+		}
+		if !anyPred && r.predicted != predClass {
+			continue
+		}
+		b.view = append(b.view, i)
+	}
+
+	if b.sortCheck.Checked {
+		sort.SliceStable(b.view, func(i, j int) bool {
+			return b.results[b.view[i]].confidence < b.results[b.view[j]].confidence
+		})
+	}
+
+	b.info.SetText(fmt.Sprintf("Ошибок: %d из %d (точность %.2f%%), отобрано: %d",
+		errors, len(b.results), 100*(1-float64(errors)/float64(len(b.results))), len(b.view)))
+	b.show(0)
+}
+
+// show отображает изображение с номером i в отфильтрованном списке
+func (b *testBrowser) show(i int) {
+	if len(b.view) == 0 {
+		b.grid.SetImage(nil)
+		b.chart.SetProbabilities(make([]float64, 10))
+		b.position.SetText("0 / 0")
+		return
+	}
+
+	b.current = min(max(i, 0), len(b.view)-1)
+	r := b.results[b.view[b.current]]
+
+	b.grid.SetImage(b.test.Images[r.index])
+	b.chart.SetProbabilities(r.probabilities)
+
+	result := "✓ Правильно"
+	if r.label != r.predicted {
+		result = "✗ Ошибка"
+	}
+	b.position.SetText(fmt.Sprintf("%d / %d (№%d)\nРеальная цифра: %d\nПредсказание: %d (%.2f%%) %s",
+		b.current+1, len(b.view), r.index, r.label, r.predicted, r.confidence*100, result))
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// EpochStats статистика одной эпохи обучения
+type EpochStats struct {
+	Epoch    int // Номер эпохи, начиная с 0
+	Epochs   int
+	Loss     float64
+	Accuracy float64
+	Elapsed  time.Duration
+}
+
+// Trainer обучает сеть мини-батчами и накапливает историю потерь и точности.
+// Обучение можно приостановить или остановить из другой горутины
+type Trainer struct {
+	Network   *Network
+	Train     *Dataset
+	Epochs    int
+	BatchSize int
+	Augmenter *Augmenter // Может быть nil
+
+	Losses     []float64 // Средняя потеря по эпохам
+	Accuracies []float64 // Точность на обучающей выборке по эпохам
+
+	OnBatch func(epoch, done, total int) // Вызывается после каждого батча
+	OnEpoch func(stats EpochStats)       // Вызывается после каждой эпохи
+
+	mu      sync.Mutex
+	resume  *sync.Cond
+	paused  bool
+	stopped bool
+}
+
+// NewTrainer создает тренер с размером батча 32
+func NewTrainer(network *Network, train *Dataset, epochs int) *Trainer {
+	t := &Trainer{
+		Network:   network,
+		Train:     train,
+		Epochs:    epochs,
+		BatchSize: 32,
+	}
+	t.resume = sync.NewCond(&t.mu)
+	return t
+}
+
+// Run обучает сеть заданное число эпох. Возвращает false, если обучение остановлено
+func (t *Trainer) Run() bool {
+	for epoch := 0; epoch < t.Epochs; epoch++ {
+		stats, ok := t.runEpoch(epoch)
+		if !ok {
+			return false
+		}
+
+		t.Losses = append(t.Losses, stats.Loss)
+		t.Accuracies = append(t.Accuracies, stats.Accuracy)
+
+		if t.OnEpoch != nil {
+			t.OnEpoch(stats)
+		}
+	}
+	return true
+}
+
+// runEpoch проходит по обучающей выборке один раз
+func (t *Trainer) runEpoch(epoch int) (EpochStats, bool) {
+	startTime := time.Now()
+	network := t.Network
+	num := t.Train.Len()
+
+	// Перемешиваем данные
+	shuffledIndices := rand.Perm(num)
+
+	var epochLoss float64
+	var correct int
+
+	// Обучение мини-батчами
+	for i := 0; i < num; i += t.BatchSize {
+		if !t.waitIfPaused() {
+			return EpochStats{}, false
+		}
+
+		end := min(i+t.BatchSize, num)
+		batchIndices := shuffledIndices[i:end]
+
+		// Прямое распространение и обратное распространение для батча
+		for _, idx := range batchIndices {
+			image := t.Train.Images[idx]
+			label := t.Train.Labels[idx]
+			if t.Augmenter != nil {
+				image = t.Augmenter.Apply(image)
+			}
+			image = network.Transforms.Apply(image)
+
+			// Прямое распространение
+			output := network.Forward(image)
+
+			// Вычисляем потерю и точность
+			epochLoss += CrossEntropyLoss(output, label)
+			if ArgMax(output) == label {
+				correct++
+			}
+
+			// Обратное распространение ошибки
+			network.Backward(image, label)
+		}
+
+		// Обновление весов после батча
+		network.UpdateWeights(len(batchIndices))
+
+		if t.OnBatch != nil {
+			t.OnBatch(epoch, end, num)
+		}
+	}
+
+	return EpochStats{
+		Epoch:    epoch,
+		Epochs:   t.Epochs,
+		Loss:     epochLoss / float64(num),
+		Accuracy: float64(correct) / float64(num),
+		Elapsed:  time.Since(startTime),
+	}, true
+}
+
+// Pause приостанавливает обучение после текущего батча
+func (t *Trainer) Pause() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.paused = true
+}
+
+// Resume продолжает приостановленное обучение
+func (t *Trainer) Resume() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.paused = false
+	t.resume.Broadcast()
+}
+
+// Stop прерывает обучение после текущего батча
+func (t *Trainer) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopped = true
+	t.resume.Broadcast()
+}
+
+// waitIfPaused блокируется на время паузы, возвращает false после Stop
+func (t *Trainer) waitIfPaused() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for t.paused && !t.stopped {
+		t.resume.Wait()
+	}
+	return !t.stopped
+}
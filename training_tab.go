@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+	"gonum.org/v1/plot/vg"
+)
+
+// publishEvery через сколько батчей вкладка рисования получает свежие веса
+const publishEvery = 200
+
+// trainingTab вкладка фонового обучения сети
+type trainingTab struct {
+	p       *Predictor
+	trainer *Trainer
+
+	epochsEntry   *widget.Entry
+	rateEntry     *widget.Entry
+	continueCheck *widget.Check
+	startBtn      *widget.Button
+	pauseBtn      *widget.Button
+	stopBtn       *widget.Button
+	saveBtn       *widget.Button
+	progress      *widget.ProgressBar
+	info          *widget.Label
+	chart         *canvas.Image
+}
+
+// buildTrainingTab создает вкладку обучения
+func (p *Predictor) buildTrainingTab() fyne.CanvasObject {
+	t := &trainingTab{p: p}
+
+	t.epochsEntry = widget.NewEntry()
+	t.epochsEntry.SetText("10")
+	t.rateEntry = widget.NewEntry()
+	t.rateEntry.SetText("0.1")
+	t.continueCheck = widget.NewCheck("Продолжить обучение текущей модели", nil)
+
+	t.startBtn = widget.NewButton("Начать", t.start)
+	t.pauseBtn = widget.NewButton("Пауза", t.togglePause)
+	t.stopBtn = widget.NewButton("Остановить", func() {
+		if t.trainer != nil {
+			t.trainer.Stop()
+		}
+	})
+	t.saveBtn = widget.NewButton("Сохранить модель...", t.saveDialog)
+	t.pauseBtn.Disable()
+	t.stopBtn.Disable()
+
+	t.progress = widget.NewProgressBar()
+	t.info = widget.NewLabel("Обучение не запущено")
+
+	t.chart = canvas.NewImageFromImage(nil)
+	t.chart.FillMode = canvas.ImageFillContain
+	t.chart.SetMinSize(fyne.NewSize(600, 360))
+
+	return container.NewBorder(
+		container.NewVBox(
+			widget.NewForm(
+				widget.NewFormItem("Эпохи", t.epochsEntry),
+				widget.NewFormItem("Скорость обучения", t.rateEntry),
+			),
+			t.continueCheck,
+			container.NewHBox(t.startBtn, t.pauseBtn, t.stopBtn, t.saveBtn),
+			t.progress,
+			t.info,
+		),
+		nil, nil, nil,
+		t.chart,
+	)
+}
+
+// start запускает обучение в фоновой горутине
+func (t *trainingTab) start() {
+	epochs, err := strconv.Atoi(t.epochsEntry.Text)
+	if err != nil || epochs <= 0 {
+		dialog.ShowError(fmt.Errorf("неверное количество эпох %q", t.epochsEntry.Text), t.p.window)
+		return
+	}
+	rate, err := strconv.ParseFloat(t.rateEntry.Text, 64)
+	if err != nil || rate <= 0 {
+		dialog.ShowError(fmt.Errorf("неверная скорость обучения %q", t.rateEntry.Text), t.p.window)
+		return
+	}
+
+	// Сеть обучения принадлежит горутине обучения, вкладка рисования получает только копии
+	var network *Network
+	if current := t.p.model.Network(); t.continueCheck.Checked && current != nil {
+		network = current.Clone()
+	} else {
+		network = NewNetwork([]int{784, 128, 64, 10})
+	}
+	network.SetLearningRate(rate)
+
+	// Тренер создается до загрузки данных, чтобы Остановить и Пауза работали и во время загрузки
+	trainer := NewTrainer(network, nil, epochs)
+	t.trainer = trainer
+
+	t.startBtn.Disable()
+	t.pauseBtn.Enable()
+	t.pauseBtn.SetText("Пауза")
+	t.stopBtn.Enable()
+	t.progress.SetValue(0)
+	t.info.SetText("Загрузка данных MNIST...")
+
+	go t.run(trainer)
+}
+
+// run выполняется в фоновой горутине
+func (t *trainingTab) run(trainer *Trainer) {
+	network, epochs := trainer.Network, trainer.Epochs
+	train, test, err := t.p.loadMNIST()
+	if err != nil {
+		fyne.Do(func() {
+			dialog.ShowError(err, t.p.window)
+			t.finished()
+		})
+		return
+	}
+
+	trainer.Train = train
+
+	trainer.OnBatch = func(epoch, done, total int) {
+		batch := (done + trainer.BatchSize - 1) / trainer.BatchSize
+		if batch%publishEvery != 0 {
+			return
+		}
+
+		t.p.publishNetwork(network.Clone(), fmt.Sprintf("обучение, эпоха %d", epoch+1))
+		fyne.Do(func() {
+			t.progress.SetValue(float64(done) / float64(total))
+			t.info.SetText(fmt.Sprintf("Эпоха %d/%d: %d/%d изображений", epoch+1, epochs, done, total))
+		})
+	}
+
+	trainer.OnEpoch = func(stats EpochStats) {
+		snapshot := network.Clone()
+		snapshot.TestAccuracy = Evaluate(snapshot, test.Images, test.Labels)
+		t.p.publishNetwork(snapshot, fmt.Sprintf("обучение, эпоха %d", stats.Epoch+1))
+
+		losses := append([]float64(nil), trainer.Losses...)
+		accuracies := append([]float64(nil), trainer.Accuracies...)
+		plot, err := TrainingPlotImage(losses, accuracies, 6*vg.Inch, 3.6*vg.Inch)
+
+		fyne.Do(func() {
+			t.progress.SetValue(1)
+			t.info.SetText(fmt.Sprintf("Эпоха %d/%d | Loss: %.4f | Accuracy: %.2f%% | Тестовая точность: %.2f%% | Время: %v",
+				stats.Epoch+1, stats.Epochs, stats.Loss, stats.Accuracy*100, snapshot.TestAccuracy*100, stats.Elapsed))
+			if err == nil {
+				t.chart.Image = plot
+				t.chart.Refresh()
+			}
+		})
+	}
+
+	completed := trainer.Run()
+
+	fyne.Do(func() {
+		if completed {
+			t.info.SetText(t.info.Text + "\nОбучение завершено")
+		} else {
+			t.info.SetText(t.info.Text + "\nОбучение остановлено")
+		}
+		t.finished()
+	})
+}
+
+// togglePause приостанавливает или продолжает обучение
+func (t *trainingTab) togglePause() {
+	if t.trainer == nil {
+		return
+	}
+
+	if t.pauseBtn.Text == "Пауза" {
+		t.trainer.Pause()
+		t.pauseBtn.SetText("Продолжить")
+	} else {
+		t.trainer.Resume()
+		t.pauseBtn.SetText("Пауза")
+	}
+}
+
+// finished возвращает кнопки в исходное состояние
+func (t *trainingTab) finished() {
+	t.trainer = nil
+	t.startBtn.Enable()
+	t.pauseBtn.Disable()
+	t.stopBtn.Disable()
+}
+
+// saveDialog сохраняет текущую модель в выбранный файл
+func (t *trainingTab) saveDialog() {
+	network := t.p.model.Network()
+	if network == nil {
+		dialog.ShowError(fmt.Errorf("нет модели для сохранения"), t.p.window)
+		return
+	}
+
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, t.p.window)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		writer.Close()
+
+		if err := network.Save(writer.URI().Path()); err != nil {
+			dialog.ShowError(err, t.p.window)
+			return
+		}
+		t.p.model.Set(network, writer.URI().Path())
+		t.p.status.SetText(t.p.model.Describe())
+	}, t.p.window)
+	save.SetFileName("mnist_model.json")
+	save.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+	save.Show()
+}
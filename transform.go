@@ -0,0 +1,296 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Transform шаг предобработки входного изображения.
+// Сохраняется вместе с моделью, поэтому параметры хранятся в полях, а не в замыканиях
+type Transform struct {
+	Type   string  `json:"type"`             // scale, standardize, deskew, center, pad
+	Factor float64 `json:"factor,omitempty"` // Множитель для scale
+	Mean   float64 `json:"mean,omitempty"`   // Среднее для standardize
+	Std    float64 `json:"std,omitempty"`    // Стандартное отклонение для standardize
+	Pad    int     `json:"pad,omitempty"`    // Ширина рамки для pad
+}
+
+// Pipeline цепочка преобразований, применяемая ко всем входам сети.
+// На вход цепочки всегда подаются изображения 0..1: загрузчики наборов делят
+// значения пикселей на PixelMax, поэтому scale нужен только для дополнительного масштаба
+type Pipeline []Transform
+
+// PixelMax наибольшее значение пикселя в файлах наборов (один байт на пиксель)
+const PixelMax = 255.0
+
+// ParsePipeline создает цепочку из строки вида "deskew,center,standardize,pad=2".
+// Параметры standardize без значений вычисляются позже через Fit
+func ParsePipeline(spec string) (Pipeline, error) {
+	var pipeline Pipeline
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(part, "=")
+		transform := Transform{Type: name}
+
+		switch name {
+		case "scale":
+			factor, err := strconv.ParseFloat(value, 64)
+			if !hasValue || err != nil {
+				return nil, fmt.Errorf("scale требует множитель, например scale=0.00392")
+			}
+			transform.Factor = factor
+		case "standardize":
+			if hasValue {
+				mean, std, ok := strings.Cut(value, "/")
+				var err1, err2 error
+				transform.Mean, err1 = strconv.ParseFloat(mean, 64)
+				transform.Std, err2 = strconv.ParseFloat(std, 64)
+				if !ok || err1 != nil || err2 != nil || transform.Std <= 0 {
+					return nil, fmt.Errorf("standardize ожидает значения вида standardize=<среднее>/<отклонение>")
+				}
+			}
+		case "pad":
+			pad, err := strconv.Atoi(value)
+			if !hasValue || err != nil || pad < 0 {
+				return nil, fmt.Errorf("pad требует ширину рамки, например pad=2")
+			}
+			transform.Pad = pad
+		case "deskew", "center":
+		default:
+			return nil, fmt.Errorf("неизвестное преобразование %q", name)
+		}
+
+		pipeline = append(pipeline, transform)
+	}
+
+	return pipeline, nil
+}
+
+// Validate проверяет цепочку, загруженную вместе с моделью: неизвестное
+// преобразование или неверный параметр иначе молча исказили бы вход сети
+func (p Pipeline) Validate() error {
+	for i, t := range p {
+		switch t.Type {
+		case "scale", "deskew", "center":
+		case "standardize":
+			if t.Std < 0 {
+				return fmt.Errorf("преобразование %d: отрицательное отклонение standardize %g", i, t.Std)
+			}
+		case "pad":
+			if t.Pad < 0 {
+				return fmt.Errorf("преобразование %d: отрицательная ширина рамки pad %d", i, t.Pad)
+			}
+		default:
+			return fmt.Errorf("преобразование %d: неизвестный тип %q", i, t.Type)
+		}
+	}
+	return nil
+}
+
+// Fit вычисляет недостающие параметры standardize по обучающим изображениям.
+// Статистика считается по выходу предшествующих преобразований
+func (p Pipeline) Fit(images [][]float64) {
+	for i := range p {
+		if p[i].Type != "standardize" || p[i].Std > 0 {
+			continue
+		}
+
+		var sum, sumSq float64
+		var count int
+		for _, image := range images {
+			for _, value := range p[:i].Apply(image) {
+				sum += value
+				sumSq += value * value
+				count++
+			}
+		}
+
+		if count == 0 {
+			p[i].Std = 1
+			continue
+		}
+
+		p[i].Mean = sum / float64(count)
+		p[i].Std = math.Sqrt(math.Max(sumSq/float64(count)-p[i].Mean*p[i].Mean, 1e-12))
+	}
+}
+
+// InputSize возвращает размер входа сети после всех преобразований
+func (p Pipeline) InputSize() int {
+	side := ImageSide
+	for _, t := range p {
+		if t.Type == "pad" {
+			side += 2 * t.Pad
+		}
+	}
+	return side * side
+}
+
+// Apply применяет цепочку к изображению 28x28, исходное изображение не изменяется
+func (p Pipeline) Apply(image []float64) []float64 {
+	side := ImageSide
+	for _, t := range p {
+		image, side = t.apply(image, side)
+	}
+	return image
+}
+
+// Backward переносит градиент по выходу цепочки на исходное изображение 28x28.
+// Deskew считается тождественным преобразованием, поэтому для него результат приближенный
+func (p Pipeline) Backward(image, grad []float64) []float64 {
+	// Входы всех шагов нужны для обратного прохода
+	inputs := make([][]float64, len(p))
+	sides := make([]int, len(p))
+	side := ImageSide
+	for i, t := range p {
+		inputs[i], sides[i] = image, side
+		image, side = t.apply(image, side)
+	}
+
+	for i := len(p) - 1; i >= 0; i-- {
+		grad = p[i].backward(inputs[i], grad, sides[i])
+	}
+	return grad
+}
+
+// String возвращает цепочку в формате ParsePipeline
+func (p Pipeline) String() string {
+	parts := make([]string, len(p))
+	for i, t := range p {
+		switch t.Type {
+		case "scale":
+			parts[i] = fmt.Sprintf("scale=%g", t.Factor)
+		case "standardize":
+			parts[i] = fmt.Sprintf("standardize=%g/%g", t.Mean, t.Std)
+		case "pad":
+			parts[i] = fmt.Sprintf("pad=%d", t.Pad)
+		default:
+			parts[i] = t.Type
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// apply применяет одно преобразование к изображению со стороной side
+func (t Transform) apply(image []float64, side int) ([]float64, int) {
+	switch t.Type {
+	case "scale":
+		result := make([]float64, len(image))
+		for i, value := range image {
+			result[i] = value * t.Factor
+		}
+		return result, side
+	case "standardize":
+		std := t.Std
+		if std == 0 {
+			std = 1
+		}
+		result := make([]float64, len(image))
+		for i, value := range image {
+			result[i] = (value - t.Mean) / std
+		}
+		return result, side
+	case "deskew":
+		return Deskew(image, side), side
+	case "center":
+		return CenterByMass(image, side, side), side
+	case "pad":
+		return padImage(image, side, t.Pad), side + 2*t.Pad
+	}
+	return image, side
+}
+
+// backward переносит градиент по выходу преобразования на его вход image со стороной side
+func (t Transform) backward(image, grad []float64, side int) []float64 {
+	switch t.Type {
+	case "scale":
+		result := make([]float64, len(grad))
+		for i, g := range grad {
+			result[i] = g * t.Factor
+		}
+		return result
+	case "standardize":
+		std := t.Std
+		if std == 0 {
+			std = 1
+		}
+		result := make([]float64, len(grad))
+		for i, g := range grad {
+			result[i] = g / std
+		}
+		return result
+	case "center":
+		dx, dy := massShift(image, side, side)
+		return shiftImage(grad, side, side, -dx, -dy)
+	case "pad":
+		newSide := side + 2*t.Pad
+		result := make([]float64, side*side)
+		for y := 0; y < side; y++ {
+			copy(result[y*side:(y+1)*side], grad[(y+t.Pad)*newSide+t.Pad:])
+		}
+		return result
+	}
+	return grad
+}
+
+// Deskew выпрямляет наклон цифры по моментам второго порядка
+// и, как в классической предобработке MNIST, переносит центр масс в центр поля
+func Deskew(image []float64, side int) []float64 {
+	var mass, cx, cy float64
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			v := image[y*side+x]
+			mass += v
+			cx += v * float64(x)
+			cy += v * float64(y)
+		}
+	}
+	if mass == 0 {
+		return image
+	}
+	cx /= mass
+	cy /= mass
+
+	var mu11, mu02 float64
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			v := image[y*side+x]
+			mu11 += v * (float64(x) - cx) * (float64(y) - cy)
+			mu02 += v * (float64(y) - cy) * (float64(y) - cy)
+		}
+	}
+	if mu02 == 0 {
+		return image
+	}
+	alpha := mu11 / mu02
+
+	// Скос вокруг центра масс, который переносится в центр поля
+	center := float64(side-1) / 2
+	result := make([]float64, len(image))
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			sx := float64(x) + alpha*(float64(y)-center) + cx - center
+			sy := float64(y) + cy - center
+			result[y*side+x] = sampleBilinear(image, side, side, sx, sy)
+		}
+	}
+
+	return result
+}
+
+// padImage добавляет вокруг изображения рамку из нулей
+func padImage(image []float64, side, pad int) []float64 {
+	newSide := side + 2*pad
+	result := make([]float64, newSide*newSide)
+	for y := 0; y < side; y++ {
+		copy(result[(y+pad)*newSide+pad:], image[y*side:(y+1)*side])
+	}
+	return result
+}
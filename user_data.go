@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	userDataPrefix    = "data/user" // Собственные рисунки: data/user-images.bin и data/user-labels.bin
+	fineTuneEpochs    = 3
+	fineTuneReplay    = 5   // Сколько примеров MNIST на один пользовательский
+	fineTuneRateScale = 0.5 // Скорость дообучения относительно исходной
+)
+
+// FineTune дообучает копию сети на пользовательских примерах. Чтобы сеть не забыла
+// MNIST, к ним подмешивается стратифицированная выборка replay размером replayRatio*user
+func FineTune(network *Network, user, replay *Dataset, epochs, replayRatio int, onEpoch func(EpochStats)) *Network {
+	tuned := network.Clone()
+	rate := network.LearningRate
+	if rate <= 0 {
+		rate = 0.1
+	}
+	tuned.SetLearningRate(rate * fineTuneRateScale)
+
+	mixed := &Dataset{}
+	mixed.Append(user)
+	if replay != nil {
+		rng := rand.New(rand.NewSource(rand.Int63()))
+		mixed.Append(replay.Sample(user.Len()*replayRatio, true, rng))
+	}
+
+	trainer := NewTrainer(tuned, mixed, epochs)
+	trainer.OnEpoch = onEpoch
+	trainer.Run()
+
+	return tuned
+}
+
+// buildCorrectionPanel создает панель для сохранения рисунка с верной меткой и дообучения
+func (p *Predictor) buildCorrectionPanel() fyne.CanvasObject {
+	labels := make([]string, 10)
+	for i := range labels {
+		labels[i] = strconv.Itoa(i)
+	}
+	labelSelect := widget.NewSelect(labels, nil)
+	labelSelect.PlaceHolder = "Верная цифра"
+
+	count := widget.NewLabel("")
+	updateCount := func() {
+		user, err := LoadBinDataset(userDataPrefix)
+		if err != nil {
+			count.SetText("Собственных рисунков: 0")
+			return
+		}
+		count.SetText(fmt.Sprintf("Собственных рисунков: %d", user.Len()))
+	}
+	updateCount()
+
+	saveBtn := widget.NewButton("Сохранить с верной меткой", func() {
+		if labelSelect.SelectedIndex() < 0 {
+			dialog.ShowInformation("Метка не выбрана", "Выберите цифру, которая нарисована на самом деле", p.window)
+			return
+		}
+
+		image := p.grid.getDataForPredict()
+		if !p.grid.MNISTBase() {
+			image = NormalizeToMNIST(image, GridSize, GridSize)
+		}
+		sample := &Dataset{
+			Images: [][]float64{image},
+			Labels: []int{labelSelect.SelectedIndex()},
+		}
+		if err := os.MkdirAll(filepath.Dir(userDataPrefix), 0755); err != nil {
+			dialog.ShowError(err, p.window)
+			return
+		}
+		if err := AppendBinDataset(sample, userDataPrefix); err != nil {
+			dialog.ShowError(err, p.window)
+			return
+		}
+
+		updateCount()
+		p.activeCanvas().Clear()
+	})
+
+	var fineTuneBtn *widget.Button
+	fineTuneBtn = widget.NewButton("Дообучить на своих рисунках", func() {
+		network := p.model.Network()
+		if network == nil {
+			dialog.ShowError(fmt.Errorf("нет модели для дообучения"), p.window)
+			return
+		}
+
+		user, err := LoadBinDataset(userDataPrefix)
+		if err != nil || user.Len() == 0 {
+			dialog.ShowError(fmt.Errorf("нет сохраненных рисунков"), p.window)
+			return
+		}
+
+		fineTuneBtn.Disable()
+		count.SetText("Дообучение...")
+
+		go func() {
+			// Без MNIST дообучаемся только на своих рисунках
+			train, test, _ := p.loadMNIST()
+
+			tuned := FineTune(network, user, train, fineTuneEpochs, fineTuneReplay, func(stats EpochStats) {
+				fyne.Do(func() {
+					count.SetText(fmt.Sprintf("Дообучение: эпоха %d/%d, Loss: %.4f", stats.Epoch+1, stats.Epochs, stats.Loss))
+				})
+			})
+
+			userAccuracy := Evaluate(tuned, user.Images, user.Labels)
+			tuned.TestAccuracy = 0
+			if test != nil {
+				tuned.TestAccuracy = Evaluate(tuned, test.Images, test.Labels)
+			}
+			p.publishNetwork(tuned, p.model.Source()+" (дообучена)")
+
+			fyne.Do(func() {
+				fineTuneBtn.Enable()
+				count.SetText(fmt.Sprintf("Собственных рисунков: %d, точность на них: %.2f%%",
+					user.Len(), userAccuracy*100))
+			})
+		}()
+	})
+
+	return container.NewVBox(
+		widget.NewSeparator(),
+		container.NewHBox(labelSelect, saveBtn),
+		fineTuneBtn,
+		count,
+	)
+}
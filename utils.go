@@ -11,6 +11,8 @@ import (
 	"gonum.org/v1/plot"
 	"gonum.org/v1/plot/plotter"
 	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/vgimg"
 )
 
 // Evaluate оценивает точность сети
@@ -18,7 +20,7 @@ func Evaluate(network *Network, images [][]float64, labels []int) float64 {
 	correct := 0
 
 	for i := 0; i < len(images); i++ {
-		output := network.Forward(images[i])
+		output := network.Predict(images[i])
 		prediction := ArgMax(output)
 
 		if prediction == labels[i] {
@@ -31,6 +33,33 @@ func Evaluate(network *Network, images [][]float64, labels []int) float64 {
 
 // PlotTrainingResults создает график обучения
 func PlotTrainingResults(losses, accuracies []float64) error {
+	p, err := NewTrainingPlot(losses, accuracies)
+	if err != nil {
+		return err
+	}
+
+	// Сохраняем график
+	if err := p.Save(10*vg.Inch, 6*vg.Inch, "training_results.png"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// TrainingPlotImage рисует график обучения в изображение заданного размера
+func TrainingPlotImage(losses, accuracies []float64, width, height vg.Length) (image.Image, error) {
+	p, err := NewTrainingPlot(losses, accuracies)
+	if err != nil {
+		return nil, err
+	}
+
+	canvas := vgimg.New(width, height)
+	p.Draw(draw.New(canvas))
+	return canvas.Image(), nil
+}
+
+// NewTrainingPlot строит график потерь и точности по эпохам
+func NewTrainingPlot(losses, accuracies []float64) (*plot.Plot, error) {
 	p := plot.New()
 	p.Title.Text = "График обучения"
 	p.X.Label.Text = "Эпоха"
@@ -45,7 +74,7 @@ func PlotTrainingResults(losses, accuracies []float64) error {
 
 	lossLine, err := plotter.NewLine(lossPoints)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	lossLine.Color = color.RGBA{R: 255, G: 0, B: 0, A: 255}
 
@@ -58,7 +87,7 @@ func PlotTrainingResults(losses, accuracies []float64) error {
 
 	accLine, err := plotter.NewLine(accPoints)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	accLine.Color = color.RGBA{R: 0, G: 0, B: 255, A: 255}
 
@@ -66,12 +95,7 @@ func PlotTrainingResults(losses, accuracies []float64) error {
 	p.Legend.Add("Loss", lossLine)
 	p.Legend.Add("Accuracy (%)", accLine)
 
-	// Сохраняем график
-	if err := p.Save(10*vg.Inch, 6*vg.Inch, "training_results.png"); err != nil {
-		return err
-	}
-
-	return nil
+	return p, nil
 }
 
 // ShowPredictions показывает примеры предсказаний
@@ -80,7 +104,7 @@ func ShowPredictions(network *Network, images [][]float64, labels []int, numExam
 	fmt.Println("=====================")
 
 	for i := 0; i < numExamples && i < len(images); i++ {
-		output := network.Forward(images[i])
+		output := network.Predict(images[i])
 		prediction := ArgMax(output)
 		confidence := output[prediction]
 
@@ -100,6 +124,22 @@ func ShowPredictions(network *Network, images [][]float64, labels []int, numExam
 
 // SaveImageAsPNG сохраняет изображение MNIST как PNG
 func SaveImageAsPNG(imageData []float64, filename string, label int) error {
+	return SavePNG(ImageFromPixels(imageData), filename)
+}
+
+// SavePNG сохраняет произвольное изображение в файл PNG
+func SavePNG(img image.Image, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return png.Encode(file, img)
+}
+
+// ImageFromPixels создает изображение 28x28 в оттенках серого из значений 0..1
+func ImageFromPixels(imageData []float64) *image.Gray {
 	img := image.NewGray(image.Rect(0, 0, 28, 28))
 
 	for y := 0; y < 28; y++ {
@@ -110,13 +150,7 @@ func SaveImageAsPNG(imageData []float64, filename string, label int) error {
 		}
 	}
 
-	file, err := os.Create(filename)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	return png.Encode(file, img)
+	return img
 }
 
 // CreateSampleImages создает примеры изображений
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// mosaicGap расстояние между плитками мозаики в пикселях
+const mosaicGap = 1
+
+// WeightImage отображает веса одного нейрона первого слоя как квадратную тепловую карту:
+// отрицательные веса синие, положительные красные, нулевые белые
+func WeightImage(weights []float64) (*image.RGBA, error) {
+	side := int(math.Sqrt(float64(len(weights))))
+	if side*side != len(weights) {
+		return nil, fmt.Errorf("вход слоя из %d значений не является квадратным изображением", len(weights))
+	}
+
+	var scale float64
+	for _, w := range weights {
+		scale = max(scale, math.Abs(w))
+	}
+	if scale == 0 {
+		scale = 1
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, side, side))
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			img.SetRGBA(x, y, divergingColor(weights[y*side+x]/scale))
+		}
+	}
+	return img, nil
+}
+
+// divergingColor переводит значение -1..1 в цвет шкалы синий-белый-красный
+func divergingColor(value float64) color.RGBA {
+	value = min(max(value, -1), 1)
+	fade := uint8(255 * (1 - math.Abs(value)))
+	if value < 0 {
+		return color.RGBA{fade, fade, 255, 255}
+	}
+	return color.RGBA{255, fade, fade, 255}
+}
+
+// WeightsMosaic собирает тепловые карты всех нейронов слоя в одну почти квадратную мозаику
+func WeightsMosaic(layer *Layer) (image.Image, error) {
+	tiles := make([]image.Image, len(layer.Weights))
+	for i, weights := range layer.Weights {
+		tile, err := WeightImage(weights)
+		if err != nil {
+			return nil, err
+		}
+		tiles[i] = tile
+	}
+	return mosaic(tiles, color.RGBA{40, 40, 40, 255}), nil
+}
+
+// mosaic раскладывает плитки одинакового размера по строкам
+func mosaic(tiles []image.Image, background color.Color) image.Image {
+	if len(tiles) == 0 {
+		return image.NewRGBA(image.Rect(0, 0, 1, 1))
+	}
+
+	cols, rows := gridShape(len(tiles))
+	size := tiles[0].Bounds().Size()
+	result := image.NewRGBA(image.Rect(0, 0,
+		cols*(size.X+mosaicGap)+mosaicGap, rows*(size.Y+mosaicGap)+mosaicGap))
+	draw.Draw(result, result.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+
+	for i, tile := range tiles {
+		x := mosaicGap + (i%cols)*(size.X+mosaicGap)
+		y := mosaicGap + (i/cols)*(size.Y+mosaicGap)
+		draw.Draw(result, image.Rect(x, y, x+size.X, y+size.Y), tile, tile.Bounds().Min, draw.Src)
+	}
+	return result
+}
+
+// gridShape возвращает число столбцов и строк почти квадратной сетки из n ячеек
+func gridShape(n int) (int, int) {
+	cols := int(math.Ceil(math.Sqrt(float64(n))))
+	if cols == 0 {
+		return 1, 1
+	}
+	return cols, (n + cols - 1) / cols
+}
+
+// ActivationHeatmap отображает активации слоя как сетку ячеек, по пикселю на нейрон.
+// Значения нормируются на максимум, если он больше 1 (например, для ReLU)
+func ActivationHeatmap(activations []float64) image.Image {
+	cols, rows := gridShape(len(activations))
+	scale := 1.0
+	for _, a := range activations {
+		scale = max(scale, a)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, cols, rows))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{40, 40, 40, 255}), image.Point{}, draw.Src)
+	for i, a := range activations {
+		img.SetRGBA(i%cols, i/cols, heatColor(a/scale))
+	}
+	return img
+}
+
+// heatColor переводит значение 0..1 в цвет шкалы черный-красный-желтый
+func heatColor(value float64) color.RGBA {
+	value = min(max(value, 0), 1)
+	if value < 0.5 {
+		return color.RGBA{uint8(510 * value), 0, 0, 255}
+	}
+	return color.RGBA{255, uint8(510 * (value - 0.5)), 0, 255}
+}
+
+// ActivationBars рисует активации слоя столбиками шириной barWidth и высотой до height
+func ActivationBars(activations []float64, barWidth, height int) image.Image {
+	scale := 1.0
+	for _, a := range activations {
+		scale = max(scale, a)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, max(len(activations)*barWidth, 1), height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{40, 40, 40, 255}), image.Point{}, draw.Src)
+	for i, a := range activations {
+		value := min(max(a/scale, 0), 1)
+		top := height - int(value*float64(height))
+		bar := image.Rect(i*barWidth, top, (i+1)*barWidth-1, height)
+		draw.Draw(img, bar, image.NewUniform(heatColor(value)), image.Point{}, draw.Src)
+	}
+	return img
+}
+
+// SaveWeightImages сохраняет в каталог тепловые карты нейронов первого слоя
+// (neuron_NNN.png) и их общую мозаику (weights.png)
+func SaveWeightImages(network *Network, dir string) error {
+	if len(network.Layers) == 0 {
+		return fmt.Errorf("в модели нет слоев")
+	}
+	layer := network.Layers[0]
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for i, weights := range layer.Weights {
+		img, err := WeightImage(weights)
+		if err != nil {
+			return err
+		}
+		if err := SavePNG(img, filepath.Join(dir, fmt.Sprintf("neuron_%03d.png", i))); err != nil {
+			return err
+		}
+	}
+
+	img, err := WeightsMosaic(layer)
+	if err != nil {
+		return err
+	}
+	return SavePNG(img, filepath.Join(dir, "weights.png"))
+}
+
+// runWeights сохраняет веса первого слоя модели в виде изображений
+func runWeights(args []string) error {
+	flags := flag.NewFlagSet("weights", flag.ExitOnError)
+	modelPath := flags.String("model", "mnist_model.json", "файл модели")
+	out := flags.String("out", "weights", "каталог для изображений")
+	flags.Parse(args)
+
+	network, err := LoadNetwork(*modelPath)
+	if err != nil {
+		return err
+	}
+
+	if err := SaveWeightImages(network, *out); err != nil {
+		return err
+	}
+
+	fmt.Printf("Сохранено %d карт весов и мозаика в %s\n", len(network.Layers[0].Weights), *out)
+	return nil
+}
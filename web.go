@@ -0,0 +1,105 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// webPage страница рисования для браузера, встроенная в программу
+//
+//go:embed web/index.html
+var webPage []byte
+
+var wsUpgrader = websocket.Upgrader{}
+
+// wsRequest сообщение страницы рисования: рисунок 28x28, нужна ли нормализация как в MNIST
+// и закончен ли рисунок (пользователь отпустил кнопку мыши)
+type wsRequest struct {
+	Pixels    []float64 `json:"pixels"`
+	Normalize bool      `json:"normalize"`
+	Final     bool      `json:"final"`
+}
+
+func (s *Server) handlePage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(webPage)
+}
+
+// handleWebSocket отвечает на каждый рисунок из соединения сообщением PredictResponse
+// или errorResponse. Модель выбирается параметром ?model= адреса соединения.
+// Соединение закрывается клиентом. Страница присылает рисунок после каждого движения мыши,
+// а в метриках классов и уверенности учитываются только сообщения с final, по одному
+// на законченный рисунок
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // Upgrade уже ответил клиенту ошибкой
+	}
+	defer conn.Close()
+	conn.SetReadLimit(maxRequestSize)
+	// Тайм-ауты HTTP-сервера остаются на соединении после Upgrade и оборвали бы рисование
+	conn.NetConn().SetDeadline(time.Time{})
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Printf("WebSocket %s: %v", r.RemoteAddr, err)
+			}
+			return
+		}
+
+		if err := conn.WriteJSON(s.predictMessage(r, message)); err != nil {
+			log.Printf("WebSocket %s: %v", r.RemoteAddr, err)
+			return
+		}
+	}
+}
+
+// predictMessage выполняет предсказание для одного сообщения WebSocket
+func (s *Server) predictMessage(r *http.Request, message []byte) any {
+	start := time.Now()
+
+	var request wsRequest
+	if err := json.Unmarshal(message, &request); err != nil {
+		return errorResponse{Error: fmt.Sprintf("неверный JSON: %v", err)}
+	}
+	if len(request.Pixels) != ImagePixels {
+		return errorResponse{Error: fmt.Sprintf("ожидается %d пикселей, получено %d", ImagePixels, len(request.Pixels))}
+	}
+
+	input := request.Pixels
+	if request.Normalize {
+		input = NormalizeToMNIST(input, ImageSide, ImageSide)
+	}
+
+	// Модель ищется для каждого сообщения, чтобы соединение переходило на новые версии
+	entry, err := s.registry.lookup(r.URL.Query().Get("model"))
+	if err != nil {
+		return errorResponse{Error: err.Error()}
+	}
+
+	output, source, err := entry.predict(r.Context(), input)
+	if err != nil {
+		return errorResponse{Error: err.Error()}
+	}
+
+	if request.Final {
+		s.metrics.observePrediction(entry.name, output)
+	}
+
+	prediction := ArgMax(output)
+	return PredictResponse{
+		Prediction:    prediction,
+		Confidence:    output[prediction],
+		Probabilities: output,
+		LatencyMs:     float64(time.Since(start).Microseconds()) / 1000,
+		Model:         source,
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	activationBarWidth  = 3
+	activationBarHeight = 60
+)
+
+// weightsTab вкладка визуализации весов первого слоя и активаций скрытых слоев
+type weightsTab struct {
+	p       *Predictor
+	network *Network // Сеть, для которой построена мозаика
+
+	mosaic      *canvas.Image
+	info        *widget.Label
+	activations *fyne.Container
+	heatmaps    []*canvas.Image
+	bars        []*canvas.Image
+}
+
+// buildWeightsTab создает вкладку визуализации сети
+func (p *Predictor) buildWeightsTab() fyne.CanvasObject {
+	t := &weightsTab{p: p}
+	p.weights = t
+
+	t.mosaic = canvas.NewImageFromImage(nil)
+	t.mosaic.ScaleMode = canvas.ImageScalePixels
+	t.mosaic.FillMode = canvas.ImageFillContain
+	t.mosaic.SetMinSize(fyne.NewSize(480, 480))
+
+	t.info = widget.NewLabel("")
+	t.activations = container.NewVBox()
+
+	exportBtn := widget.NewButton("Экспорт PNG...", t.exportDialog)
+
+	t.update(p.model.Network(), nil)
+
+	return container.NewHBox(
+		container.NewBorder(
+			widget.NewLabel("Веса первого слоя (синий - отрицательные, красный - положительные)"),
+			container.NewHBox(exportBtn, t.info),
+			nil, nil,
+			t.mosaic,
+		),
+		container.NewVBox(
+			widget.NewLabel("Активации скрытых слоев для текущего рисунка"),
+			t.activations,
+		),
+	)
+}
+
+// update перестраивает мозаику при смене сети и показывает активации для входа input
+func (t *weightsTab) update(network *Network, input []float64) {
+	if network == nil {
+		t.info.SetText("Модель не загружена")
+		return
+	}
+
+	if network != t.network {
+		t.network = network
+		t.rebuild()
+	}
+
+	if input == nil {
+		input = make([]float64, ImagePixels)
+	}
+	activations := network.LayerActivations(input)
+	for i := range t.heatmaps {
+		t.heatmaps[i].Image = ActivationHeatmap(activations[i])
+		t.heatmaps[i].Refresh()
+		t.bars[i].Image = ActivationBars(activations[i], activationBarWidth, activationBarHeight)
+		t.bars[i].Refresh()
+	}
+}
+
+// rebuild строит мозаику весов и по паре изображений на каждый скрытый слой
+func (t *weightsTab) rebuild() {
+	img, err := WeightsMosaic(t.network.Layers[0])
+	if err != nil {
+		t.info.SetText(err.Error())
+		t.mosaic.Image = nil
+	} else {
+		t.info.SetText(fmt.Sprintf("Нейронов: %d", len(t.network.Layers[0].Weights)))
+		t.mosaic.Image = img
+	}
+	t.mosaic.Refresh()
+
+	t.heatmaps = nil
+	t.bars = nil
+	t.activations.RemoveAll()
+	for i, layer := range t.network.Layers[:len(t.network.Layers)-1] {
+		cols, rows := gridShape(len(layer.Weights))
+
+		heatmap := canvas.NewImageFromImage(nil)
+		heatmap.ScaleMode = canvas.ImageScalePixels
+		heatmap.SetMinSize(fyne.NewSize(float32(cols*12), float32(rows*12)))
+
+		bars := canvas.NewImageFromImage(nil)
+		bars.ScaleMode = canvas.ImageScalePixels
+		bars.SetMinSize(fyne.NewSize(float32(len(layer.Weights)*activationBarWidth), activationBarHeight))
+
+		t.heatmaps = append(t.heatmaps, heatmap)
+		t.bars = append(t.bars, bars)
+		t.activations.Add(widget.NewLabel(fmt.Sprintf("Слой %d (%d нейронов)", i+1, len(layer.Weights))))
+		t.activations.Add(container.NewHBox(heatmap))
+		t.activations.Add(container.NewHBox(bars))
+	}
+}
+
+// exportDialog сохраняет карты весов в выбранный каталог
+func (t *weightsTab) exportDialog() {
+	network := t.p.model.Network()
+	if network == nil {
+		dialog.ShowError(fmt.Errorf("модель не загружена"), t.p.window)
+		return
+	}
+
+	dialog.ShowFolderOpen(func(dir fyne.ListableURI, err error) {
+		if err != nil {
+			dialog.ShowError(err, t.p.window)
+			return
+		}
+		if dir == nil {
+			return
+		}
+
+		if err := SaveWeightImages(network, dir.Path()); err != nil {
+			dialog.ShowError(err, t.p.window)
+			return
+		}
+		dialog.ShowInformation("Экспорт", fmt.Sprintf("Карты весов сохранены в %s", dir.Path()), t.p.window)
+	}, t.p.window)
+}